@@ -1,6 +1,7 @@
 package kaginawa
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,6 +12,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -18,16 +21,29 @@ const (
 	serversResource = "/servers"
 )
 
+// DefaultUserAgent is the User-Agent header value sent by default.
+const DefaultUserAgent = "kaginawa-sdk-go"
+
 // Client is a Kaginawa Server REST API client.
 type Client struct {
 	endpoint   string
 	apiKey     string
-	client     http.Client
+	client     *http.Client
+	timeout    *time.Duration
+	transport  http.RoundTripper
+	userAgent  string
+	headers    http.Header
+	baseCtx    context.Context
+	retry      RetryPolicy
+	limiter    *rateLimiter
+	inFlight   chan struct{}
+	closeMutex sync.Mutex
 	closeError error
 }
 
 // NewClient will creates Kaginawa client object.
-func NewClient(endpoint, apiKey string) (*Client, error) {
+// The behavior of the client can be customized by options such as WithHTTPClient and WithTimeout.
+func NewClient(endpoint, apiKey string, opts ...Option) (*Client, error) {
 	if len(endpoint) == 0 {
 		return nil, errors.New("most specify an endpoint")
 	}
@@ -37,35 +53,165 @@ func NewClient(endpoint, apiKey string) (*Client, error) {
 	if len(apiKey) == 0 {
 		return nil, errors.New("most specify an api key")
 	}
-	return &Client{
-		endpoint: endpoint,
-		apiKey:   apiKey,
-		client:   http.Client{},
-	}, nil
+	c := &Client{
+		endpoint:  endpoint,
+		apiKey:    apiKey,
+		client:    &http.Client{},
+		userAgent: DefaultUserAgent,
+		headers:   http.Header{},
+		baseCtx:   context.Background(),
+		retry:     DefaultRetryPolicy(),
+		limiter:   newRateLimiter(0, 1),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeout != nil {
+		c.client.Timeout = *c.timeout
+	}
+	if c.transport != nil {
+		c.client.Transport = c.transport
+	}
+	return c, nil
 }
 
 func (c *Client) request(ctx context.Context, method, url string, body io.Reader, expectedStatus int) (*http.Response, error) {
+	return c.do(ctx, method, url, body, expectedStatus, method == http.MethodGet)
+}
+
+// do sends the request and retries it according to the retry policy if retryable is true.
+func (c *Client) do(ctx context.Context, method, url string, body io.Reader, expectedStatus int, retryable bool) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = ioutil.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+	}
+	ctx, cancel := c.mergeContext(ctx)
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, method, url, payload)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		canRetry := retryable && attempt < c.retry.MaxAttempts
+		release, err := c.acquire(ctx)
+		if err != nil {
+			cancel()
+			return nil, &RequestError{Method: method, URL: url, Attempts: attempt - 1, Err: err}
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			release()
+			if !canRetry || ctx.Err() != nil {
+				cancel()
+				return nil, &RequestError{Method: method, URL: url, Attempts: attempt, Err: err}
+			}
+			if err := sleep(ctx, c.retry.delay(attempt, "")); err != nil {
+				cancel()
+				return nil, &RequestError{Method: method, URL: url, Attempts: attempt, Err: err}
+			}
+			continue
+		}
+		if resp.StatusCode == expectedStatus {
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: func() { release(); cancel() }}
+			return resp, nil
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			throttle, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+			if !ok {
+				throttle = defaultThrottleDelay
+			}
+			c.limiter.pause(throttle)
+		}
+		if canRetry && c.retry.retryableStatus(resp.StatusCode) {
+			delay := c.retry.delay(attempt, resp.Header.Get("Retry-After"))
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+			c.safeClose(resp.Body)
+			release()
+			if err := sleep(ctx, delay); err != nil {
+				cancel()
+				return nil, &RequestError{Method: method, URL: url, Attempts: attempt, Err: err}
+			}
+			continue
+		}
+		apiErr := newAPIError(req, resp)
+		apiErr.Attempts = attempt
+		c.safeClose(resp.Body)
+		release()
+		cancel()
+		return nil, apiErr
+	}
+}
+
+// newRequest builds a request with common headers.
+func (c *Client) newRequest(ctx context.Context, method, url string, payload []byte) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build request: %v", err)
 	}
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if len(c.userAgent) > 0 {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", "token "+c.apiKey)
-	if body != nil {
+	if payload != nil {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+	return req, nil
+}
+
+// mergeContext derives a request context that is canceled when either ctx or the base context is done.
+func (c *Client) mergeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	merged, cancel := context.WithCancel(ctx)
+	if c.baseCtx.Done() == nil {
+		return merged, cancel
 	}
-	if resp.StatusCode != expectedStatus {
-		return nil, fmt.Errorf("kaginawa server respond HTTP %s", resp.Status)
+	if c.baseCtx.Err() != nil {
+		cancel()
+		return merged, cancel
 	}
-	return resp, nil
+	go func() {
+		select {
+		case <-c.baseCtx.Done():
+			cancel()
+		case <-merged.Done():
+		}
+	}()
+	return merged, cancel
+}
+
+// cancelBody releases the request context when the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the request context.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func (c *Client) safeClose(closer io.Closer) {
-	c.closeError = closer.Close()
+	err := closer.Close()
+	c.closeMutex.Lock()
+	c.closeError = err
+	c.closeMutex.Unlock()
 }
 
 // FindNode finds a report by id.
@@ -82,14 +228,13 @@ func (c *Client) FindNode(ctx context.Context, id string) (*Report, error) {
 	return &report, nil
 }
 
-// ListAliveNodes queries list of all alive nodes.
-// It is considered alive if the last received time is within 5 minutes.
-func (c *Client) ListAliveNodes(ctx context.Context, thresholdMin int) ([]Report, error) {
-	values := url.Values{"projection": {"id"}}
-	if thresholdMin > 0 {
-		values.Add("minutes", strconv.Itoa(thresholdMin))
+// ListNodes queries list of reports that match the query.
+func (c *Client) ListNodes(ctx context.Context, query NodeQuery) ([]Report, error) {
+	path := c.endpoint + nodesResource
+	if values := query.values(); len(values) > 0 {
+		path += "?" + values.Encode()
 	}
-	resp, err := c.request(ctx, http.MethodGet, c.endpoint+nodesResource+"?"+values.Encode(), nil, http.StatusOK)
+	resp, err := c.request(ctx, http.MethodGet, path, nil, http.StatusOK)
 	if err != nil {
 		return nil, err
 	}
@@ -101,10 +246,38 @@ func (c *Client) ListAliveNodes(ctx context.Context, thresholdMin int) ([]Report
 	return reports, nil
 }
 
+// ListAliveNodes queries list of all alive nodes.
+// It is considered alive if the last received time is within 5 minutes.
+func (c *Client) ListAliveNodes(ctx context.Context, thresholdMin int) ([]Report, error) {
+	return c.ListNodes(ctx, NodeQuery{Projection: ProjectionID, Minutes: thresholdMin})
+}
+
+// ListAliveNodesWithin queries list of all nodes that reported within the duration.
+// The duration is rounded up to minutes.
+func (c *Client) ListAliveNodesWithin(ctx context.Context, within time.Duration) ([]Report, error) {
+	minutes := int(within / time.Minute)
+	if within%time.Minute > 0 {
+		minutes++
+	}
+	return c.ListAliveNodes(ctx, minutes)
+}
+
 // ListNodesByCustomID queries list of reports by custom-id.
 func (c *Client) ListNodesByCustomID(ctx context.Context, customID string) ([]Report, error) {
-	values := url.Values{"custom-id": {customID}}
-	resp, err := c.request(ctx, http.MethodGet, c.endpoint+nodesResource+"?"+values.Encode(), nil, http.StatusOK)
+	return c.ListNodes(ctx, NodeQuery{CustomID: customID})
+}
+
+// ListHistories queries list of histories by id.
+func (c *Client) ListHistories(ctx context.Context, id string, beginTimestamp, endTimestamp int64) ([]Report, error) {
+	values := NodeQuery{Projection: ProjectionMeasurement}.values()
+	if beginTimestamp > 0 {
+		values.Add("begin", strconv.FormatInt(beginTimestamp, 10))
+	}
+	if endTimestamp > 0 {
+		values.Add("end", strconv.FormatInt(endTimestamp, 10))
+	}
+	path := fmt.Sprintf("%s%s/%s/histories?%s", c.endpoint, nodesResource, id, values.Encode())
+	resp, err := c.request(ctx, http.MethodGet, path, nil, http.StatusOK)
 	if err != nil {
 		return nil, err
 	}
@@ -116,26 +289,61 @@ func (c *Client) ListNodesByCustomID(ctx context.Context, customID string) ([]Re
 	return reports, nil
 }
 
-// ListHistories queries list of histories by id.
-func (c *Client) ListHistories(ctx context.Context, id string, beginTimestamp, endTimestamp int64) ([]Report, error) {
-	values := url.Values{"projection": {"measurement"}}
+// ListHistoriesBetween queries list of histories by id between from and to.
+// Zero time means unbounded.
+func (c *Client) ListHistoriesBetween(ctx context.Context, id string, from, to time.Time) ([]Report, error) {
+	var begin, end int64
+	if !from.IsZero() {
+		begin = from.Unix()
+	}
+	if !to.IsZero() {
+		end = to.Unix()
+	}
+	return c.ListHistories(ctx, id, begin, end)
+}
+
+// DeleteNode deletes the node and its latest report by id.
+func (c *Client) DeleteNode(ctx context.Context, id string) error {
+	if len(id) == 0 {
+		return errors.New("most specify an id")
+	}
+	resp, err := c.request(ctx, http.MethodDelete, c.endpoint+nodesResource+"/"+id, nil, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	c.safeClose(resp.Body)
+	return nil
+}
+
+// DeleteHistories deletes histories of the node in the range and returns the number of deleted histories.
+// Zero timestamp means unbounded. If dryRun is true, it only counts the histories to be deleted.
+func (c *Client) DeleteHistories(ctx context.Context, id string, beginTimestamp, endTimestamp int64, dryRun bool) (int, error) {
+	if len(id) == 0 {
+		return 0, errors.New("most specify an id")
+	}
+	values := url.Values{}
 	if beginTimestamp > 0 {
 		values.Add("begin", strconv.FormatInt(beginTimestamp, 10))
 	}
 	if endTimestamp > 0 {
 		values.Add("end", strconv.FormatInt(endTimestamp, 10))
 	}
+	if dryRun {
+		values.Add("dry-run", "true")
+	}
 	path := fmt.Sprintf("%s%s/%s/histories?%s", c.endpoint, nodesResource, id, values.Encode())
-	resp, err := c.request(ctx, http.MethodGet, path, nil, http.StatusOK)
+	resp, err := c.request(ctx, http.MethodDelete, path, nil, http.StatusOK)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 	defer c.safeClose(resp.Body)
-	var reports []Report
-	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	var result struct {
+		Count int `json:"count"`
 	}
-	return reports, nil
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return result.Count, nil
 }
 
 // FindSSHServerByHostname finds a SSH server entry by hostname.
@@ -152,27 +360,74 @@ func (c *Client) FindSSHServerByHostname(ctx context.Context, hostname string) (
 	return &server, nil
 }
 
-// Command submits a command to the node through Kaginawa Server.
-func (c *Client) Command(ctx context.Context, id, command, user, key, password string, timeoutSec int) (string, error) {
-	form := url.Values{"command": {command}, "user": {user}}
-	if len(key) > 0 {
-		form.Add("key", key)
+// ListSSHServers queries list of all SSH server entries.
+func (c *Client) ListSSHServers(ctx context.Context) ([]SSHServer, error) {
+	resp, err := c.request(ctx, http.MethodGet, c.endpoint+serversResource, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
 	}
-	if len(password) > 0 {
-		form.Add("password", password)
+	defer c.safeClose(resp.Body)
+	var servers []SSHServer
+	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
 	}
-	if timeoutSec > 0 {
-		form.Add("timeout", strconv.Itoa(timeoutSec))
+	return servers, nil
+}
+
+// CreateSSHServer registers a new SSH server entry.
+func (c *Client) CreateSSHServer(ctx context.Context, server SSHServer) error {
+	if err := server.Validate(); err != nil {
+		return fmt.Errorf("invalid ssh server: %w", err)
 	}
-	body := strings.NewReader(form.Encode())
-	resp, err := c.request(ctx, http.MethodPost, c.endpoint+nodesResource+"/"+id+"/command", body, http.StatusOK)
+	body := strings.NewReader(server.form().Encode())
+	resp, err := c.request(ctx, http.MethodPost, c.endpoint+serversResource, body, http.StatusCreated)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer c.safeClose(resp.Body)
-	result, err := ioutil.ReadAll(resp.Body)
+	c.safeClose(resp.Body)
+	return nil
+}
+
+// UpdateSSHServer replaces the SSH server entry that has the same hostname.
+func (c *Client) UpdateSSHServer(ctx context.Context, server SSHServer) error {
+	if err := server.Validate(); err != nil {
+		return fmt.Errorf("invalid ssh server: %w", err)
+	}
+	body := strings.NewReader(server.form().Encode())
+	path := c.endpoint + serversResource + "/" + url.PathEscape(server.Host)
+	resp, err := c.request(ctx, http.MethodPut, path, body, http.StatusOK)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
+		return err
+	}
+	c.safeClose(resp.Body)
+	return nil
+}
+
+// DeleteSSHServer deletes the SSH server entry by hostname.
+func (c *Client) DeleteSSHServer(ctx context.Context, hostname string) error {
+	if len(hostname) == 0 {
+		return errors.New("most specify a hostname")
+	}
+	path := c.endpoint + serversResource + "/" + url.PathEscape(hostname)
+	resp, err := c.request(ctx, http.MethodDelete, path, nil, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	c.safeClose(resp.Body)
+	return nil
+}
+
+// Command submits a command to the node through Kaginawa Server.
+// It is a shorthand of RunCommand that returns only the output of the command.
+func (c *Client) Command(ctx context.Context, id, command, user, key, password string, timeoutSec int) (string, error) {
+	result, err := c.RunCommand(ctx, id, CommandRequest{
+		Command:    command,
+		User:       user,
+		Credential: legacyCredential{key: key, password: password},
+		Timeout:    time.Duration(timeoutSec) * time.Second,
+	})
+	if err != nil {
+		return "", err
 	}
-	return string(result), nil
+	return result.Stdout, nil
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 const testAPIKey = "test123"
@@ -278,3 +279,290 @@ func TestCommand(t *testing.T) {
 		t.Errorf("Command expected %s, got %s", "success", result)
 	}
 }
+
+func TestListSSHServers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if authorization != "token "+testAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			t.Errorf("invalid api key: %s", authorization)
+			return
+		}
+		if r.URL.Path != "/servers" {
+			w.WriteHeader(http.StatusNotFound)
+			t.Errorf("invalid path: %s", r.URL.Path)
+			return
+		}
+		expected, err := ioutil.ReadFile("testdata/servers_multiple.json")
+		if err != nil {
+			t.Errorf("failed to initialize testdata: %v", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(expected); err != nil {
+			t.Errorf("failed to write testdata response: %v", err)
+			return
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	servers, err := client.ListSSHServers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("expected %d record, got %d record(s)", 2, len(servers))
+	}
+	if servers[1].Port != 2222 {
+		t.Errorf("Port expected %d, got %d", 2222, servers[1].Port)
+	}
+}
+
+func TestCreateSSHServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/servers" {
+			w.WriteHeader(http.StatusNotFound)
+			t.Errorf("invalid request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			t.Errorf("failed to parse form: %v", err)
+			return
+		}
+		if r.PostForm.Get("host") != "example.com" || r.PostForm.Get("port") != "22" {
+			t.Errorf("unexpected form: %v", r.PostForm)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server := SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Password: "test-pw"}
+	if err := client.CreateSSHServer(context.Background(), server); err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if err := client.CreateSSHServer(context.Background(), SSHServer{Host: "example.com"}); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestUpdateSSHServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/servers/example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			t.Errorf("invalid request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			t.Errorf("failed to parse form: %v", err)
+			return
+		}
+		if r.PostForm.Get("key") != "test key" {
+			t.Errorf("unexpected form: %v", r.PostForm)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server := SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Key: "test key"}
+	if err := client.UpdateSSHServer(context.Background(), server); err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+}
+
+func TestDeleteSSHServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/servers/example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			t.Errorf("invalid request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.DeleteSSHServer(context.Background(), "example.com"); err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+}
+
+func TestDeleteNode(t *testing.T) {
+	testID := "f0:18:98:eb:c7:27"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/nodes/"+testID {
+			w.WriteHeader(http.StatusNotFound)
+			t.Errorf("invalid request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.DeleteNode(context.Background(), testID); err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+}
+
+func TestDeleteHistories(t *testing.T) {
+	testID := "b8:27:eb:36:83:e0"
+	tests := []struct {
+		dryRun   bool
+		expected int
+	}{
+		{dryRun: true, expected: 10},
+		{dryRun: false, expected: 5},
+	}
+	for i, d := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodDelete || r.URL.Path != "/nodes/"+testID+"/histories" {
+				w.WriteHeader(http.StatusNotFound)
+				t.Errorf("test %d: invalid request: %s %s", i, r.Method, r.URL.Path)
+				return
+			}
+			query := r.URL.Query()
+			if query.Get("begin") != "1500000000" || query.Get("end") != "1600000000" {
+				t.Errorf("test %d: unexpected query: %v", i, query)
+			}
+			body := `{"count":5}`
+			if query.Get("dry-run") == "true" {
+				body = `{"count":10}`
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}))
+		client, err := NewClient(ts.URL, testAPIKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		count, err := client.DeleteHistories(context.Background(), testID, 1500000000, 1600000000, d.dryRun)
+		ts.Close()
+		if err != nil {
+			t.Fatalf("test %d: unexpexted error: %v", i, err)
+		}
+		if count != d.expected {
+			t.Errorf("test %d: expected count %d, got %d", i, d.expected, count)
+		}
+	}
+}
+
+func TestListNodes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if authorization != "token "+testAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			t.Errorf("invalid api key: %s", authorization)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("runtime") != "darwin amd64" || query.Get("limit") != "10" {
+			t.Errorf("unexpected query: %v", query)
+		}
+		if _, ok := query["projection"]; ok {
+			t.Errorf("unexpected projection: %s", query.Get("projection"))
+		}
+		expected, err := ioutil.ReadFile("testdata/nodes_multiple.json")
+		if err != nil {
+			t.Errorf("failed to initialize testdata: %v", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(expected); err != nil {
+			t.Errorf("failed to write testdata response: %v", err)
+			return
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reports, err := client.ListNodes(context.Background(), NodeQuery{Runtime: "darwin amd64", Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("expected %d record, got %d record(s)", 2, len(reports))
+	}
+}
+
+func TestListAliveNodesWithin(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{input: 5 * time.Minute, expected: "5"},
+		{input: 90 * time.Second, expected: "2"},
+		{input: 0, expected: ""},
+	}
+	for i, d := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if minutes := r.URL.Query().Get("minutes"); minutes != d.expected {
+				t.Errorf("test %d: minutes expected %s, got %s", i, d.expected, minutes)
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte("[]")); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}))
+		client, err := NewClient(ts.URL, testAPIKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = client.ListAliveNodesWithin(context.Background(), d.input)
+		ts.Close()
+		if err != nil {
+			t.Fatalf("test %d: unexpexted error: %v", i, err)
+		}
+	}
+}
+
+func TestListHistoriesBetween(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("begin") != "1500000000" {
+			t.Errorf("begin expected %s, got %s", "1500000000", query.Get("begin"))
+		}
+		if _, ok := query["end"]; ok {
+			t.Errorf("unexpected end: %s", query.Get("end"))
+		}
+		expected, err := ioutil.ReadFile("testdata/histories_multiple.json")
+		if err != nil {
+			t.Errorf("failed to initialize testdata: %v", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(expected); err != nil {
+			t.Errorf("failed to write testdata response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reports, err := client.ListHistoriesBetween(context.Background(), "b8:27:eb:36:83:e0", time.Unix(1500000000, 0), time.Time{})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("expected %d record, got %d record(s)", 2, len(reports))
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+func (a *app) listNodes(args []string) error {
+	flags := a.newFlagSet("nodes list", "[flags]")
+	var q kaginawa.NodeQuery
+	flags.StringVar(&q.CustomID, "custom-id", "", "filter by custom id")
+	flags.StringVar(&q.Hostname, "hostname", "", "filter by hostname")
+	flags.StringVar(&q.Runtime, "runtime", "", "filter by runtime")
+	flags.StringVar(&q.AgentVersion, "agent-version", "", "filter by agent version")
+	flags.StringVar(&q.SSHServerHost, "ssh-server", "", "filter by ssh server host")
+	flags.IntVar(&q.Minutes, "minutes", 0, "list only nodes reported within the minutes")
+	flags.IntVar(&q.Limit, "limit", 0, "maximum number of nodes")
+	flags.IntVar(&q.Offset, "offset", 0, "number of nodes to skip")
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	reports, err := a.client.ListNodes(a.ctx, q)
+	if err != nil {
+		return err
+	}
+	return a.out.printReports(reports)
+}
+
+func (a *app) showNode(args []string) error {
+	flags := a.newFlagSet("node show", "<id>")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	report, err := a.client.FindNode(a.ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.out.printReports([]kaginawa.Report{*report})
+}
+
+func (a *app) deleteNode(args []string) error {
+	flags := a.newFlagSet("node delete", "<id>")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	return a.client.DeleteNode(a.ctx, flags.Arg(0))
+}
+
+func (a *app) listHistories(args []string) error {
+	flags := a.newFlagSet("histories", "[flags] <id>")
+	begin := flags.String("begin", "24h", "begin time (RFC 3339, unix seconds or duration ago)")
+	end := flags.String("end", "", "end time (RFC 3339, unix seconds or duration ago), default now")
+	changes := flags.Bool("changes", false, "print only changes between consecutive histories")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	from, to, err := parseRange(*begin, *end)
+	if err != nil {
+		return err
+	}
+	reports, err := a.client.ListHistoriesBetween(a.ctx, flags.Arg(0), from, to)
+	if err != nil {
+		return err
+	}
+	if *changes {
+		return a.out.printChanges(kaginawa.HistoryChanges(reports))
+	}
+	return a.out.printReports(reports)
+}
+
+func (a *app) deleteHistories(args []string) error {
+	flags := a.newFlagSet("histories delete", "[flags] <id>")
+	begin := flags.String("begin", "", "begin time (RFC 3339, unix seconds or duration ago)")
+	end := flags.String("end", "", "end time (RFC 3339, unix seconds or duration ago)")
+	dryRun := flags.Bool("dry-run", false, "only count histories to be deleted")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	from, to, err := parseRange(*begin, *end)
+	if err != nil {
+		return err
+	}
+	var b, e int64
+	if !from.IsZero() {
+		b = from.Unix()
+	}
+	if !to.IsZero() {
+		e = to.Unix()
+	}
+	count, err := a.client.DeleteHistories(a.ctx, flags.Arg(0), b, e, *dryRun)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Fprintf(a.stdout, "%d histories will be deleted\n", count)
+	} else {
+		fmt.Fprintf(a.stdout, "%d histories deleted\n", count)
+	}
+	return nil
+}
+
+func (a *app) listServers(args []string) error {
+	flags := a.newFlagSet("servers list", "")
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	servers, err := a.client.ListSSHServers(a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.printServers(servers)
+}
+
+func (a *app) showServer(args []string) error {
+	flags := a.newFlagSet("servers show", "<host>")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	server, err := a.client.FindSSHServerByHostname(a.ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.out.printServers([]kaginawa.SSHServer{*server})
+}
+
+func (a *app) saveServer(action string, args []string) error {
+	flags := a.newFlagSet("servers "+action, "[flags] <host>")
+	port := flags.Int("port", 22, "port number")
+	user := flags.String("user", "", "login user")
+	keyFile := flags.String("key-file", "", "private key file")
+	password := flags.String("password", "", "login password")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	server := kaginawa.SSHServer{Host: flags.Arg(0), Port: *port, User: *user, Password: *password}
+	if len(*keyFile) > 0 {
+		key, err := ioutil.ReadFile(*keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read key file: %w", err)
+		}
+		server.Key = string(key)
+	}
+	if action == "create" {
+		return a.client.CreateSSHServer(a.ctx, server)
+	}
+	return a.client.UpdateSSHServer(a.ctx, server)
+}
+
+func (a *app) deleteServer(args []string) error {
+	flags := a.newFlagSet("servers delete", "<host>")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	return a.client.DeleteSSHServer(a.ctx, flags.Arg(0))
+}
+
+func (a *app) exec(args []string) error {
+	flags := a.newFlagSet("exec", "[flags] <id> <command...>")
+	user := flags.String("u", "", "login user of the node")
+	password := flags.String("password", "", "login password of the node")
+	keyFile := flags.String("i", "", "private key file of the node")
+	serverCredential := flags.Bool("server-credential", false, "use the credential of the ssh server entry")
+	timeout := flags.Duration("timeout", 0, "command timeout")
+	if err := parseArgs(flags, args, 2); err != nil {
+		return err
+	}
+	req := kaginawa.CommandRequest{
+		Command: strings.Join(flags.Args()[1:], " "),
+		User:    *user,
+		Timeout: *timeout,
+	}
+	if len(req.User) == 0 {
+		req.User = a.profile.SSHUser
+	}
+	if !*serverCredential && len(*keyFile) == 0 && len(*password) == 0 {
+		*keyFile = a.profile.SSHKeyPath
+	}
+	switch {
+	case *serverCredential:
+		req.Credential = kaginawa.SSHServerCredential{}
+	case len(*keyFile) > 0:
+		key, err := ioutil.ReadFile(*keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read key file: %w", err)
+		}
+		req.Credential = kaginawa.KeyCredential{Key: string(key)}
+	case len(*password) > 0:
+		req.Credential = kaginawa.PasswordCredential{Password: *password}
+	}
+	result, err := a.client.RunCommand(a.ctx, flags.Arg(0), req)
+	if err != nil {
+		return err
+	}
+	switch a.out.format {
+	case formatJSON, formatCSV:
+		header := []string{"node_id", "exit_code", "duration", "stdout", "stderr"}
+		exitCode := ""
+		if result.ExitCodeKnown {
+			exitCode = strconv.Itoa(result.ExitCode)
+		}
+		row := []string{result.NodeID, exitCode, result.Duration.String(), result.Stdout, result.Stderr}
+		if err := a.out.print(header, [][]string{row}, result); err != nil {
+			return err
+		}
+	default:
+		fmt.Fprint(a.stdout, result.Stdout)
+		fmt.Fprint(a.stderr, result.Stderr)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("exit status %d", result.ExitCode)
+	}
+	return nil
+}
+
+// parseRange parses the begin and end flags. Empty value means zero time.
+func parseRange(begin, end string) (time.Time, time.Time, error) {
+	from, err := parseTime(begin)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid begin: %w", err)
+	}
+	to, err := parseTime(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end: %w", err)
+	}
+	return from, to, nil
+}
+
+// parseTime parses RFC 3339, unix seconds or duration ago such as "24h".
+func parseTime(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported time format: %s", value)
+}
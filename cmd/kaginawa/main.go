@@ -0,0 +1,161 @@
+// Command kaginawa is the command-line client of Kaginawa Server.
+//
+// Usage:
+//
+//	kaginawa [global flags] <command> [flags] [args]
+//
+// Commands:
+//
+//	nodes list                 list nodes
+//	node show <id>             show the latest report of the node
+//	node delete <id>           delete the node
+//	histories <id>             list histories (or changes with -changes) of the node
+//	histories delete <id>      delete histories of the node
+//	servers list               list SSH servers
+//	servers show <host>        show the SSH server
+//	servers create|update      create or update the SSH server
+//	servers delete <host>      delete the SSH server
+//	exec <id> <command...>     execute the command on the node
+//	ssh <id>                   open an interactive shell on the node
+//
+// The endpoint and the API key are read from flags, KAGINAWA_ENDPOINT / KAGINAWA_API_KEY
+// environment variables or the profile in ~/.config/kaginawa/config, in this order.
+// The exec and ssh commands fall back to ssh_user and ssh_key_path of the profile.
+// The config file is not read when both -e and -k are given, unless -p is also given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// errUsage is returned when the command line is invalid.
+var errUsage = errors.New("invalid usage")
+
+// app holds the global settings of the command.
+type app struct {
+	ctx     context.Context
+	client  *kaginawa.Client
+	profile kaginawa.Profile
+	out     *printer
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("kaginawa", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	endpoint := flags.String("e", "", "endpoint (https://...), overrides "+kaginawa.EnvEndpoint)
+	apiKey := flags.String("k", "", "api key, overrides "+kaginawa.EnvAPIKey)
+	profile := flags.String("p", "", "profile name in the config file, overrides "+kaginawa.EnvProfile)
+	format := flags.String("o", formatTable, "output format (table, json or csv)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: kaginawa [global flags] <command> [flags] [args]")
+		fmt.Fprintln(stderr, "commands: nodes list, node show|delete, histories [delete], servers list|show|create|update|delete, exec, ssh")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	out, err := newPrinter(stdout, *format)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	p, err := resolveProfile(ctx, *endpoint, *apiKey, *profile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	client, err := p.NewClient(ctx, kaginawa.WithUserAgent("kaginawa-cli"))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	a := &app{ctx: ctx, client: client, profile: p, out: out, stdin: stdin, stdout: stdout, stderr: stderr}
+	if err := a.dispatch(flags.Args()); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func (a *app) dispatch(args []string) error {
+	command, rest := args[0], args[1:]
+	sub := ""
+	if len(rest) > 0 {
+		sub = rest[0]
+	}
+	switch {
+	case command == "nodes" && sub == "list":
+		return a.listNodes(rest[1:])
+	case command == "node" && sub == "show":
+		return a.showNode(rest[1:])
+	case command == "node" && sub == "delete":
+		return a.deleteNode(rest[1:])
+	case command == "histories" && sub == "delete":
+		return a.deleteHistories(rest[1:])
+	case command == "histories":
+		return a.listHistories(rest)
+	case command == "servers" && sub == "list":
+		return a.listServers(rest[1:])
+	case command == "servers" && sub == "show":
+		return a.showServer(rest[1:])
+	case command == "servers" && (sub == "create" || sub == "update"):
+		return a.saveServer(sub, rest[1:])
+	case command == "servers" && sub == "delete":
+		return a.deleteServer(rest[1:])
+	case command == "exec":
+		return a.exec(rest)
+	case command == "ssh":
+		return a.shell(rest)
+	default:
+		fmt.Fprintf(a.stderr, "unknown command: %s\n", strings.TrimSpace(command+" "+sub))
+		return errUsage
+	}
+}
+
+// newFlagSet creates the flag set of the sub command.
+func (a *app) newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: kaginawa %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseArgs parses the flags and checks the number of positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string, min int) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() < min {
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	"github.com/kaginawa/kaginawa-sdk-go/kaginawatest"
+)
+
+func newTestServer(t *testing.T) *kaginawatest.Server {
+	s := kaginawatest.NewServer()
+	t.Cleanup(s.Close)
+	s.AddReport(kaginawa.Report{ID: "b8:27:eb:36:83:e0", CustomID: "test-rpi", Hostname: "test-rpi.local",
+		SSHServerHost: "example.com", SSHRemotePort: 41383, Success: true, ServerTime: 1587337308})
+	s.AddReport(kaginawa.Report{ID: "f0:18:98:eb:c7:27", CustomID: "test-mac", Hostname: "test-mac.local",
+		Success: true, ServerTime: 1587337308})
+	s.AddSSHServer(kaginawa.SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Password: "test-pw"})
+	return s
+}
+
+func runTest(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	defaults := map[string]string{
+		kaginawa.EnvConfig:        filepath.Join(t.TempDir(), "config"),
+		kaginawa.EnvProfile:       "",
+		kaginawa.EnvEndpoint:      "",
+		kaginawa.EnvAPIKey:        "",
+		kaginawa.EnvAPIKeyCommand: "",
+	}
+	for k, v := range defaults {
+		if _, ok := env[k]; !ok {
+			t.Setenv(k, v)
+		}
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestNodesList(t *testing.T) {
+	s := newTestServer(t)
+	code, stdout, stderr := runTest(t, nil, "-e", s.URL, "-k", s.APIKey, "nodes", "list", "-custom-id", "test-rpi")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "b8:27:eb:36:83:e0") || strings.Contains(stdout, "f0:18:98:eb:c7:27") {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	if !strings.Contains(stdout, "example.com:41383") {
+		t.Errorf("expected ssh server in output: %s", stdout)
+	}
+}
+
+func TestNodeShowJSON(t *testing.T) {
+	s := newTestServer(t)
+	env := map[string]string{kaginawa.EnvEndpoint: s.URL, kaginawa.EnvAPIKey: s.APIKey}
+	code, stdout, stderr := runTest(t, env, "-o", "json", "node", "show", "f0:18:98:eb:c7:27")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	var reports []kaginawa.Report
+	if err := json.Unmarshal([]byte(stdout), &reports); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Hostname != "test-mac.local" {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+}
+
+func TestServersListCSVWithProfile(t *testing.T) {
+	s := newTestServer(t)
+	config := filepath.Join(t.TempDir(), "config")
+	content := "[default]\nendpoint = http://invalid\n\n[staging]\nendpoint = " + s.URL + "\napi_key = " + s.APIKey + "\n"
+	if err := ioutil.WriteFile(config, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	env := map[string]string{kaginawa.EnvConfig: config}
+	code, stdout, stderr := runTest(t, env, "-p", "staging", "-o", "csv", "servers", "list")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	expected := "host,port,user,key,password\nexample.com,22,kaginawa,false,true\n"
+	if stdout != expected {
+		t.Errorf("expected %q, got %q", expected, stdout)
+	}
+}
+
+func TestFlagsSkipBrokenConfig(t *testing.T) {
+	s := newTestServer(t)
+	config := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(config, []byte("endpoint without section\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	env := map[string]string{kaginawa.EnvConfig: config}
+	code, _, stderr := runTest(t, env, "-e", s.URL, "-k", s.APIKey, "nodes", "list")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if code, _, _ := runTest(t, env, "-e", s.URL, "nodes", "list"); code == 0 {
+		t.Error("expected the broken config to be reported without -k")
+	}
+}
+
+func TestUnknownProfile(t *testing.T) {
+	s := newTestServer(t)
+	config := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(config, []byte("[production]\nendpoint = "+s.URL+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	env := map[string]string{kaginawa.EnvConfig: config}
+	tests := [][]string{
+		{"-p", "prodution", "-e", s.URL, "-k", s.APIKey, "nodes", "list"},
+		{"-p", "prodution", "nodes", "list"},
+	}
+	for i, args := range tests {
+		code, _, stderr := runTest(t, env, args...)
+		if code == 0 || !strings.Contains(stderr, "profile not found") {
+			t.Errorf("test %d: expected profile not found, got %d: %s", i, code, stderr)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	s := newTestServer(t)
+	s.HandleCommand("b8:27:eb:36:83:e0", "uname -a", kaginawatest.CommandResponse{Stdout: "Linux test-rpi\n"})
+	code, stdout, stderr := runTest(t, nil, "-e", s.URL, "-k", s.APIKey,
+		"exec", "-u", "pi", "-password", "raspberry", "b8:27:eb:36:83:e0", "uname", "-a")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if stdout != "Linux test-rpi\n" {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	if stderr != "" {
+		t.Errorf("unexpected stderr: %q", stderr)
+	}
+	form := s.Requests()[0].Form
+	if form.Get("user") != "pi" || form.Get("password") != "raspberry" {
+		t.Errorf("unexpected form: %v", form)
+	}
+}
+
+func TestExecWithProfileDefaults(t *testing.T) {
+	s := newTestServer(t)
+	s.HandleCommand("b8:27:eb:36:83:e0", "uptime", kaginawatest.CommandResponse{Stdout: "up 3 days\n"})
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "id_ed25519")
+	if err := ioutil.WriteFile(keyPath, []byte("test-key"), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	config := filepath.Join(dir, "config")
+	content := "[default]\nendpoint = " + s.URL + "\napi_key_command = echo " + s.APIKey +
+		"\nssh_user = pi\nssh_key_path = " + keyPath + "\n"
+	if err := ioutil.WriteFile(config, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	code, stdout, stderr := runTest(t, map[string]string{kaginawa.EnvConfig: config}, "exec", "b8:27:eb:36:83:e0", "uptime")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if stdout != "up 3 days\n" {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	form := s.Requests()[0].Form
+	if form.Get("user") != "pi" || form.Get("key") != "test-key" {
+		t.Errorf("unexpected form: %v", form)
+	}
+}
+
+func TestHistoryChanges(t *testing.T) {
+	s := newTestServer(t)
+	s.AddReport(kaginawa.Report{ID: "dc:a6:32:00:00:01", KernelVersion: "5.10.17", ServerTime: 1587338508})
+	s.AddReport(kaginawa.Report{ID: "dc:a6:32:00:00:01", KernelVersion: "5.4.51", ServerTime: 1587337308})
+	s.AddReport(kaginawa.Report{ID: "dc:a6:32:00:00:01", KernelVersion: "5.4.51", ServerTime: 1587337908})
+	code, stdout, stderr := runTest(t, nil, "-e", s.URL, "-k", s.APIKey,
+		"histories", "-begin", "1587337300", "-end", "1587338600", "-changes", "dc:a6:32:00:00:01")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	expected := "2020-04-19T23:21:48Z\n  kernel_version: 5.4.51 -> 5.10.17\n"
+	if stdout != expected {
+		t.Errorf("expected %q, got %q", expected, stdout)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	s := newTestServer(t)
+	tests := [][]string{
+		{},
+		{"-e", s.URL, "-k", s.APIKey, "unknown"},
+		{"-e", s.URL, "-k", s.APIKey, "node", "show"},
+		{"-e", s.URL, "-k", s.APIKey, "ssh"},
+		{"-e", s.URL, "-k", s.APIKey, "-o", "xml", "nodes", "list"},
+		{"nodes", "list"},
+	}
+	for i, args := range tests {
+		if code, _, _ := runTest(t, nil, args...); code != 2 {
+			t.Errorf("test %d: expected exit code %d, got %d", i, 2, code)
+		}
+	}
+}
+
+func TestSSHWithoutCredential(t *testing.T) {
+	s := newTestServer(t)
+	code, _, stderr := runTest(t, nil, "-e", s.URL, "-k", s.APIKey, "ssh", "-u", "pi", "b8:27:eb:36:83:e0")
+	if code != 1 {
+		t.Errorf("expected exit code %d, got %d", 1, code)
+	}
+	if !strings.Contains(stderr, "credential") {
+		t.Errorf("unexpected error output: %s", stderr)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	s := newTestServer(t)
+	code, _, stderr := runTest(t, nil, "-e", s.URL, "-k", s.APIKey, "node", "show", "00:00:00:00:00:00")
+	if code != 1 {
+		t.Errorf("expected exit code %d, got %d", 1, code)
+	}
+	if !strings.Contains(stderr, "404") {
+		t.Errorf("unexpected error output: %s", stderr)
+	}
+}
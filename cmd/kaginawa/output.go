@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// printer writes records in the selected format.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+// print writes the rows as table or CSV, or v as JSON.
+func (p *printer) print(header []string, rows [][]string, v interface{}) error {
+	switch p.format {
+	case formatJSON:
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case formatCSV:
+		w := csv.NewWriter(p.w)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+var reportHeader = []string{"id", "custom_id", "hostname", "runtime", "agent_version", "ssh_server", "success", "server_time"}
+
+func (p *printer) printReports(reports []kaginawa.Report) error {
+	rows := make([][]string, 0, len(reports))
+	for _, r := range reports {
+		ssh := ""
+		if r.SSHRemotePort > 0 {
+			ssh = r.SSHServerHost + ":" + strconv.Itoa(r.SSHRemotePort)
+		}
+		rows = append(rows, []string{
+			r.ID,
+			r.CustomID,
+			r.Hostname,
+			r.Runtime,
+			r.AgentVersion,
+			ssh,
+			strconv.FormatBool(r.Success),
+			r.Timestamp().UTC().Format(time.RFC3339),
+		})
+	}
+	return p.print(reportHeader, rows, reports)
+}
+
+var serverHeader = []string{"host", "port", "user", "key", "password"}
+
+func (p *printer) printServers(servers []kaginawa.SSHServer) error {
+	rows := make([][]string, 0, len(servers))
+	for _, s := range servers {
+		rows = append(rows, []string{
+			s.Host,
+			strconv.Itoa(s.Port),
+			s.User,
+			strconv.FormatBool(len(s.Key) > 0),
+			strconv.FormatBool(len(s.Password) > 0),
+		})
+	}
+	// Secrets are masked in all formats.
+	masked := make([]kaginawa.SSHServer, len(servers))
+	for i, s := range servers {
+		masked[i] = s
+		if len(s.Key) > 0 {
+			masked[i].Key = "***"
+		}
+		if len(s.Password) > 0 {
+			masked[i].Password = "***"
+		}
+	}
+	return p.print(serverHeader, rows, masked)
+}
+
+var changeHeader = []string{"time", "field", "kind", "old", "new"}
+
+// changeRow is the JSON representation of a change.
+type changeRow struct {
+	Time  time.Time `json:"time"`
+	Field string    `json:"field"`
+	Kind  string    `json:"kind"`
+	Old   string    `json:"old"`
+	New   string    `json:"new"`
+}
+
+func (p *printer) printChanges(points []kaginawa.ChangePoint) error {
+	if p.format == formatTable {
+		for _, point := range points {
+			fmt.Fprintln(p.w, point.Time.UTC().Format(time.RFC3339))
+			for _, c := range point.Changes {
+				fmt.Fprintln(p.w, "  "+c.String())
+			}
+		}
+		return nil
+	}
+	rows := [][]string{}
+	changes := []changeRow{}
+	for _, point := range points {
+		for _, c := range point.Changes {
+			rows = append(rows, []string{point.Time.UTC().Format(time.RFC3339), c.Field, c.Kind.String(), c.Old, c.New})
+			changes = append(changes, changeRow{Time: point.Time.UTC(), Field: c.Field, Kind: c.Kind.String(), Old: c.Old, New: c.New})
+		}
+	}
+	return p.print(changeHeader, rows, changes)
+}
@@ -0,0 +1,36 @@
+//go:build !unix
+
+package main
+
+import (
+	"time"
+
+	kssh "github.com/kaginawa/kaginawa-sdk-go/ssh"
+	"golang.org/x/term"
+)
+
+// resizePollInterval is the interval of checking the terminal size where SIGWINCH is not available.
+const resizePollInterval = 500 * time.Millisecond
+
+// watchResize forwards the terminal size to the session by polling until stop is called.
+func watchResize(fd int, session *kssh.Session) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(resizePollInterval)
+		defer ticker.Stop()
+		width, height, _ := term.GetSize(fd)
+		for {
+			select {
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err == nil && (w != width || h != height) {
+					width, height = w, h
+					_ = session.Resize(width, height)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
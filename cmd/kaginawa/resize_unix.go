@@ -0,0 +1,35 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	kssh "github.com/kaginawa/kaginawa-sdk-go/ssh"
+	"golang.org/x/term"
+)
+
+// watchResize forwards the terminal size to the session on SIGWINCH until stop is called.
+func watchResize(fd int, session *kssh.Session) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if width, height, err := term.GetSize(fd); err == nil {
+					_ = session.Resize(width, height)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
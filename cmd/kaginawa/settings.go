@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// resolveProfile merges flags, environment variables and the profile, in this order of precedence.
+// The config file is not read when both endpoint and apiKey are given without a profile name.
+// A missing profile is only an error if the name is given explicitly.
+func resolveProfile(ctx context.Context, endpoint, apiKey, name string) (kaginawa.Profile, error) {
+	var profile kaginawa.Profile
+	if len(endpoint) == 0 || len(apiKey) == 0 || len(name) > 0 {
+		var err error
+		profile, err = kaginawa.LoadProfile(name)
+		if err != nil && (len(name) > 0 || !errors.Is(err, kaginawa.ErrProfileNotFound)) {
+			return profile, err
+		}
+	}
+	if len(endpoint) > 0 {
+		profile.Endpoint = endpoint
+	}
+	if len(apiKey) > 0 {
+		profile.APIKey = apiKey
+	}
+	if len(profile.Endpoint) == 0 {
+		return profile, fmt.Errorf("most specify an endpoint (-e, %s or profile)", kaginawa.EnvEndpoint)
+	}
+	key, err := profile.ResolveAPIKey(ctx)
+	if err != nil {
+		return profile, err
+	}
+	if len(key) == 0 {
+		return profile, fmt.Errorf("most specify an api key (-k, %s or profile)", kaginawa.EnvAPIKey)
+	}
+	profile.APIKey = key
+	return profile, nil
+}
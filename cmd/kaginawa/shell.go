@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	kssh "github.com/kaginawa/kaginawa-sdk-go/ssh"
+	xssh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+func (a *app) shell(args []string) error {
+	flags := a.newFlagSet("ssh", "[flags] <id>")
+	user := flags.String("u", "", "login user of the node")
+	password := flags.String("password", "", "login password of the node")
+	keyFile := flags.String("i", "", "private key file of the node")
+	knownHosts := flags.String("known-hosts", "", "known_hosts file (default ~/.ssh/known_hosts)")
+	insecure := flags.Bool("insecure", false, "skip host key verification")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	if len(*user) == 0 {
+		*user = a.profile.SSHUser
+	}
+	if len(*user) == 0 {
+		return fmt.Errorf("most specify a login user (-u or ssh_user of the profile)")
+	}
+	if len(*keyFile) == 0 && len(*password) == 0 {
+		*keyFile = a.profile.SSHKeyPath
+	}
+	var auth []xssh.AuthMethod
+	if len(*keyFile) > 0 {
+		key, err := ioutil.ReadFile(*keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read key file: %w", err)
+		}
+		signer, err := xssh.ParsePrivateKey(key)
+		if err != nil {
+			return fmt.Errorf("failed to parse key file: %w", err)
+		}
+		auth = append(auth, xssh.PublicKeys(signer))
+	}
+	if len(*password) > 0 {
+		auth = append(auth, xssh.Password(*password))
+	}
+	if len(auth) == 0 {
+		return fmt.Errorf("most specify a credential (-i, -password or ssh_key_path of the profile)")
+	}
+	hostKeyCallback, err := hostKeyCallback(*knownHosts, *insecure)
+	if err != nil {
+		return err
+	}
+	dialer := &kssh.Dialer{
+		Client:                a.client,
+		ServerHostKeyCallback: hostKeyCallback,
+		NodeHostKeyCallback:   hostKeyCallback,
+		NodeUser:              *user,
+		NodeAuth:              auth,
+	}
+	opts := kssh.ShellOptions{Stdin: a.stdin, Stdout: a.stdout, Stderr: a.stderr}
+	fd := -1
+	if f, ok := a.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fd = int(f.Fd())
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return fmt.Errorf("failed to get terminal size: %w", err)
+		}
+		opts.Terminal = &kssh.Terminal{Term: os.Getenv("TERM"), Width: width, Height: height}
+	}
+	session, err := dialer.Shell(a.ctx, flags.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	if fd >= 0 {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to make terminal raw: %w", err)
+		}
+		defer func() { _ = term.Restore(fd, state) }()
+		stop := watchResize(fd, session)
+		defer stop()
+	}
+	status, err := session.Wait()
+	if err != nil {
+		return err
+	}
+	if status != 0 {
+		return fmt.Errorf("exit status %d", status)
+	}
+	return nil
+}
+
+// hostKeyCallback builds the host key verifier from the known_hosts file.
+func hostKeyCallback(path string, insecure bool) (xssh.HostKeyCallback, error) {
+	if insecure {
+		return xssh.InsecureIgnoreHostKey(), nil
+	}
+	if len(path) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find home directory: %w", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found, specify -known-hosts or -insecure", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %w", err)
+	}
+	return callback, nil
+}
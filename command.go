@@ -0,0 +1,200 @@
+package kaginawa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Credential is the SSH credential used to log in to the node.
+// Available implementations are PasswordCredential, KeyCredential and SSHServerCredential.
+type Credential interface {
+	apply(ctx context.Context, c *Client, id string, req *CommandRequest, form url.Values, result *CommandResult) error
+}
+
+// PasswordCredential authenticates with the password.
+type PasswordCredential struct {
+	Password string
+}
+
+func (p PasswordCredential) apply(_ context.Context, _ *Client, _ string, _ *CommandRequest, form url.Values, _ *CommandResult) error {
+	form.Set("password", p.Password)
+	return nil
+}
+
+// KeyCredential authenticates with the PEM encoded private key.
+type KeyCredential struct {
+	Key string
+}
+
+func (k KeyCredential) apply(_ context.Context, _ *Client, _ string, _ *CommandRequest, form url.Values, _ *CommandResult) error {
+	form.Set("key", k.Key)
+	return nil
+}
+
+// SSHServerCredential authenticates with the key or password of the SSH server entry that the node is connected to.
+// The user of the SSH server entry is used if the user of the request is empty.
+type SSHServerCredential struct{}
+
+func (SSHServerCredential) apply(ctx context.Context, c *Client, id string, req *CommandRequest, form url.Values, result *CommandResult) error {
+	node, err := c.FindNode(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find node: %w", err)
+	}
+	if len(node.SSHServerHost) == 0 {
+		return fmt.Errorf("node %s is not connected to any ssh server", id)
+	}
+	server, err := c.FindSSHServerByHostname(ctx, node.SSHServerHost)
+	if err != nil {
+		return fmt.Errorf("failed to find ssh server: %w", err)
+	}
+	return resolvedServerCredential{server: *server}.apply(ctx, c, id, req, form, result)
+}
+
+// resolvedServerCredential is SSHServerCredential with the SSH server entry already looked up.
+type resolvedServerCredential struct {
+	server SSHServer
+}
+
+func (r resolvedServerCredential) apply(_ context.Context, _ *Client, _ string, req *CommandRequest, form url.Values, result *CommandResult) error {
+	if len(req.User) == 0 {
+		form.Set("user", r.server.User)
+	}
+	if len(r.server.Key) > 0 {
+		form.Set("key", r.server.Key)
+	}
+	if len(r.server.Password) > 0 {
+		form.Set("password", r.server.Password)
+	}
+	result.SSHServerHost = r.server.Host
+	return nil
+}
+
+// legacyCredential carries both key and password for the positional Command method.
+type legacyCredential struct {
+	key      string
+	password string
+}
+
+func (l legacyCredential) apply(_ context.Context, _ *Client, _ string, _ *CommandRequest, form url.Values, _ *CommandResult) error {
+	if len(l.key) > 0 {
+		form.Set("key", l.key)
+	}
+	if len(l.password) > 0 {
+		form.Set("password", l.password)
+	}
+	return nil
+}
+
+// CommandRequest defines a command to be executed on the node.
+type CommandRequest struct {
+	// Command is the command line to be executed.
+	Command string
+
+	// User is the login user of the node.
+	User string
+
+	// Credential is the SSH credential of the user.
+	Credential Credential
+
+	// Timeout is the time limit of the command execution. Zero means the server default.
+	// The value is rounded up to seconds.
+	Timeout time.Duration
+}
+
+// CommandResult is the result of the command execution.
+type CommandResult struct {
+	// NodeID is the id of the node that executed the command.
+	NodeID string
+
+	// SSHServerHost is the hostname of the SSH server that the command went through, if known.
+	SSHServerHost string
+
+	// Stdout is the standard output of the command.
+	// If the server responds in plain text, it holds the merged output of stdout and stderr.
+	Stdout string
+
+	// Stderr is the standard error of the command.
+	// Only available if the server reports it separately from the standard output.
+	Stderr string
+
+	// ExitCode is the exit status of the command. Meaningful only if ExitCodeKnown is true.
+	ExitCode int
+
+	// ExitCodeKnown reports whether the server reported the exit status.
+	// It is false if the server responds in plain text, so ExitCode 0 does not mean success.
+	ExitCodeKnown bool
+
+	// Duration is the elapsed time of the request.
+	Duration time.Duration
+}
+
+// commandResponse is the JSON representation of the command response.
+type commandResponse struct {
+	Stdout        string `json:"stdout"`
+	Stderr        string `json:"stderr"`
+	ExitCode      *int   `json:"exit_code"`
+	SSHServerHost string `json:"ssh_server_host"`
+}
+
+// RunCommand submits a command to the node through Kaginawa Server and returns the structured result.
+func (c *Client) RunCommand(ctx context.Context, id string, req CommandRequest) (*CommandResult, error) {
+	if len(id) == 0 {
+		return nil, errors.New("most specify an id")
+	}
+	if len(req.Command) == 0 {
+		return nil, errors.New("most specify a command")
+	}
+	result := &CommandResult{NodeID: id}
+	form := url.Values{"command": {req.Command}, "user": {req.User}}
+	if req.Credential != nil {
+		if err := req.Credential.apply(ctx, c, id, &req, form, result); err != nil {
+			return nil, err
+		}
+	}
+	if req.Timeout > 0 {
+		sec := int(req.Timeout / time.Second)
+		if req.Timeout%time.Second > 0 {
+			sec++
+		}
+		form.Add("timeout", strconv.Itoa(sec))
+	}
+	body := strings.NewReader(form.Encode())
+	start := time.Now()
+	resp, err := c.do(ctx, http.MethodPost, c.endpoint+nodesResource+"/"+id+"/command", body, http.StatusOK, c.retry.RetryCommand)
+	if err != nil {
+		return nil, classifyCommandError(err)
+	}
+	defer c.safeClose(resp.Body)
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	result.Duration = time.Since(start)
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		result.Stdout = string(raw)
+		return result, nil
+	}
+	var decoded commandResponse
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	result.Stdout = decoded.Stdout
+	result.Stderr = decoded.Stderr
+	if decoded.ExitCode != nil {
+		result.ExitCode = *decoded.ExitCode
+		result.ExitCodeKnown = true
+	}
+	if len(decoded.SSHServerHost) > 0 {
+		result.SSHServerHost = decoded.SSHServerHost
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package kaginawa
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunCommandWithJSONResponse(t *testing.T) {
+	testID := "f0:18:98:eb:c7:27"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			t.Errorf("failed to parse form: %v", err)
+			return
+		}
+		if r.PostForm.Get("password") != "raspberry" || r.PostForm.Get("timeout") != "2" {
+			t.Errorf("unexpected form: %v", r.PostForm)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		body := `{"stdout":"out","stderr":"err","exit_code":3,"ssh_server_host":"example.com"}`
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := client.RunCommand(context.Background(), testID, CommandRequest{
+		Command:    "false",
+		User:       "pi",
+		Credential: PasswordCredential{Password: "raspberry"},
+		Timeout:    1500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if result.Stdout != "out" || result.Stderr != "err" || result.ExitCode != 3 || !result.ExitCodeKnown {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.NodeID != testID || result.SSHServerHost != "example.com" {
+		t.Errorf("unexpected route: %+v", result)
+	}
+}
+
+func TestRunCommandWithPlainTextResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("merged output\n")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := client.RunCommand(context.Background(), "f0:18:98:eb:c7:27", CommandRequest{
+		Command:    "uptime",
+		User:       "pi",
+		Credential: PasswordCredential{Password: "raspberry"},
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if result.Stdout != "merged output\n" || result.ExitCodeKnown {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunCommandWithSSHServerCredential(t *testing.T) {
+	testID := "f0:18:98:eb:c7:27"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var file string
+		switch r.URL.Path {
+		case "/nodes/" + testID:
+			file = "testdata/node.json"
+		case "/servers/example.com":
+			file = "testdata/servers_single.json"
+		case "/nodes/" + testID + "/command":
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				t.Errorf("failed to parse form: %v", err)
+				return
+			}
+			if r.PostForm.Get("user") != "kaginawa" || r.PostForm.Get("password") != "test-pw" {
+				t.Errorf("unexpected form: %v", r.PostForm)
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte("success")); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			t.Errorf("invalid path: %s", r.URL.Path)
+			return
+		}
+		expected, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Errorf("failed to initialize testdata: %v", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(expected); err != nil {
+			t.Errorf("failed to write testdata response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := client.RunCommand(context.Background(), testID, CommandRequest{
+		Command:    "uptime",
+		Credential: SSHServerCredential{},
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if result.Stdout != "success" || result.ExitCode != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.SSHServerHost != "example.com" {
+		t.Errorf("SSHServerHost expected %s, got %s", "example.com", result.SSHServerHost)
+	}
+}
+
+func TestRunCommandWithoutCommand(t *testing.T) {
+	client, err := NewClient("http://localhost:3000", testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.RunCommand(context.Background(), "f0:18:98:eb:c7:27", CommandRequest{}); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
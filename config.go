@@ -0,0 +1,261 @@
+package kaginawa
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Environment variables that override the config file.
+const (
+	EnvConfig        = "KAGINAWA_CONFIG"
+	EnvProfile       = "KAGINAWA_PROFILE"
+	EnvEndpoint      = "KAGINAWA_ENDPOINT"
+	EnvAPIKey        = "KAGINAWA_API_KEY"
+	EnvAPIKeyCommand = "KAGINAWA_API_KEY_COMMAND"
+)
+
+// DefaultProfileName is the profile name used when not specified.
+const DefaultProfileName = "default"
+
+// ErrProfileNotFound is returned when the profile is not defined in the config file.
+var ErrProfileNotFound = errors.New("kaginawa: profile not found")
+
+// Profile is a named set of connection settings.
+type Profile struct {
+	// Name is the name of the profile.
+	Name string
+
+	// Endpoint is the URL of Kaginawa Server.
+	Endpoint string
+
+	// APIKey is the API key of Kaginawa Server.
+	APIKey string
+
+	// APIKeyCommand is the shell command that prints the API key, used when APIKey is empty.
+	APIKeyCommand string
+
+	// SSHUser is the default login user of nodes.
+	SSHUser string
+
+	// SSHKeyPath is the default private key file of nodes.
+	SSHKeyPath string
+
+	// Timeout is the time limit of each HTTP request. Zero means no limit.
+	Timeout time.Duration
+}
+
+// Config is the set of profiles loaded from the config file.
+//
+// The config file is an INI style text such as:
+//
+//	[default]
+//	endpoint = https://kaginawa.example.com
+//	api_key_command = pass show kaginawa/production
+//	ssh_user = pi
+//	ssh_key_path = ~/.ssh/id_ed25519
+//	timeout = 30s
+//
+//	[staging]
+//	endpoint = https://staging.kaginawa.example.com
+//	api_key = xxxxxxxx
+type Config struct {
+	Profiles map[string]Profile
+}
+
+// DefaultConfigPath returns the path of the config file.
+// KAGINAWA_CONFIG takes precedence over ~/.config/kaginawa/config (or $XDG_CONFIG_HOME/kaginawa/config).
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(EnvConfig); len(path) > 0 {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, "kaginawa", "config"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "kaginawa", "config"), nil
+}
+
+// LoadConfig reads the config file. A missing file results in an empty config.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{Profiles: map[string]Profile{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config: %w", err)
+	}
+	defer f.Close()
+	return ParseConfig(f)
+}
+
+// ParseConfig parses the INI style config. Unknown keys are ignored.
+func ParseConfig(r io.Reader) (*Config, error) {
+	config := &Config{Profiles: map[string]Profile{}}
+	var current *Profile
+	flush := func() {
+		if current != nil {
+			config.Profiles[current.Name] = *current
+		}
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			profile := config.Profiles[name]
+			profile.Name = name
+			current = &profile
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("config line %d: expected key = value", n)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("config line %d: key outside of profile section", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		switch key {
+		case "endpoint":
+			current.Endpoint = value
+		case "api_key":
+			current.APIKey = value
+		case "api_key_command":
+			current.APIKeyCommand = value
+		case "ssh_user":
+			current.SSHUser = value
+		case "ssh_key_path":
+			current.SSHKeyPath = expandHome(value)
+		case "timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("config line %d: invalid timeout: %v", n, err)
+			}
+			current.Timeout = d
+		default:
+			// Unknown keys are ignored, so that configs written for newer versions keep working.
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	flush()
+	return config, nil
+}
+
+// Profile returns the profile by name.
+func (c *Config) Profile(name string) (Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{Name: name}, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	return profile, nil
+}
+
+// LoadProfile loads the profile from the default config file and applies environment variable overrides.
+// Empty name means KAGINAWA_PROFILE or "default". A missing profile is not an error if
+// KAGINAWA_ENDPOINT and KAGINAWA_API_KEY (or KAGINAWA_API_KEY_COMMAND) are set.
+func LoadProfile(name string) (Profile, error) {
+	if len(name) == 0 {
+		name = os.Getenv(EnvProfile)
+	}
+	if len(name) == 0 {
+		name = DefaultProfileName
+	}
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return Profile{}, err
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile, notFound := config.Profile(name)
+	if v := os.Getenv(EnvEndpoint); len(v) > 0 {
+		profile.Endpoint = v
+	}
+	if v := os.Getenv(EnvAPIKey); len(v) > 0 {
+		profile.APIKey = v
+		profile.APIKeyCommand = ""
+	} else if v := os.Getenv(EnvAPIKeyCommand); len(v) > 0 {
+		profile.APIKey = ""
+		profile.APIKeyCommand = v
+	}
+	hasKey := len(profile.APIKey) > 0 || len(profile.APIKeyCommand) > 0
+	if notFound != nil && (len(profile.Endpoint) == 0 || !hasKey) {
+		return profile, notFound
+	}
+	return profile, nil
+}
+
+// ResolveAPIKey returns APIKey, or the output of APIKeyCommand.
+func (p Profile) ResolveAPIKey(ctx context.Context) (string, error) {
+	if len(p.APIKey) > 0 || len(p.APIKeyCommand) == 0 {
+		return p.APIKey, nil
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.APIKeyCommand)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.APIKeyCommand)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run api key command: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// NewClient creates a client from the profile. The profile timeout is applied before opts.
+func (p Profile) NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	apiKey, err := p.ResolveAPIKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.Timeout > 0 {
+		opts = append([]Option{WithTimeout(p.Timeout)}, opts...)
+	}
+	return NewClient(p.Endpoint, apiKey, opts...)
+}
+
+// NewClientFromProfile creates a client from the named profile of the default config file.
+// See LoadProfile for the profile resolution.
+func NewClientFromProfile(name string, opts ...Option) (*Client, error) {
+	profile, err := LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return profile.NewClient(context.Background(), opts...)
+}
+
+// expandHome replaces the leading "~/" with the home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,175 @@
+package kaginawa
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `# kaginawa config
+[default]
+endpoint = https://kaginawa.example.com
+api_key = default-key
+ssh_user = pi
+ssh_key_path = /home/pi/.ssh/id_ed25519
+timeout = 30s
+
+; staging environment
+[staging]
+endpoint = https://staging.example.com
+api_key_command = echo staging-key
+`
+
+func writeTestConfig(t *testing.T, content string) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvProfile, "")
+	t.Setenv(EnvEndpoint, "")
+	t.Setenv(EnvAPIKey, "")
+	t.Setenv(EnvAPIKeyCommand, "")
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Profiles) != 2 {
+		t.Fatalf("expected %d profiles, got %d", 2, len(config.Profiles))
+	}
+	p, err := config.Profile("default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Profile{
+		Name:       "default",
+		Endpoint:   "https://kaginawa.example.com",
+		APIKey:     "default-key",
+		SSHUser:    "pi",
+		SSHKeyPath: "/home/pi/.ssh/id_ed25519",
+		Timeout:    30 * time.Second,
+	}
+	if p != expected {
+		t.Errorf("expected %+v, got %+v", expected, p)
+	}
+	if _, err := config.Profile("production"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected ErrProfileNotFound, got %v", err)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []string{
+		"endpoint = https://example.com\n",
+		"[default]\nendpoint\n",
+		"[default]\ntimeout = soon\n",
+	}
+	for i, content := range tests {
+		if _, err := ParseConfig(strings.NewReader(content)); err == nil {
+			t.Errorf("test %d: expected error, got nil", i)
+		}
+	}
+}
+
+func TestParseConfigIgnoresUnknownKeys(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader("[default]\nendpoint = https://example.com\ncolor = auto\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p, err := config.Profile("default"); err != nil || p.Endpoint != "https://example.com" {
+		t.Errorf("unexpected profile: %+v (%v)", p, err)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Profiles) != 0 {
+		t.Errorf("expected empty config, got %v", config.Profiles)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	writeTestConfig(t, testConfig)
+	p, err := LoadProfile("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name != DefaultProfileName || p.APIKey != "default-key" {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+
+	t.Setenv(EnvProfile, "staging")
+	p, err = LoadProfile("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := p.ResolveAPIKey(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "staging-key" {
+		t.Errorf("expected %s, got %s", "staging-key", key)
+	}
+
+	t.Setenv(EnvEndpoint, "https://override.example.com")
+	t.Setenv(EnvAPIKey, "override-key")
+	p, err = LoadProfile("default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Endpoint != "https://override.example.com" || p.APIKey != "override-key" || p.SSHUser != "pi" {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+}
+
+func TestLoadProfileNotFound(t *testing.T) {
+	writeTestConfig(t, testConfig)
+	if _, err := LoadProfile("production"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected ErrProfileNotFound, got %v", err)
+	}
+
+	// Environment variables alone are enough.
+	t.Setenv(EnvEndpoint, "https://override.example.com")
+	t.Setenv(EnvAPIKey, "override-key")
+	p, err := LoadProfile("production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Endpoint != "https://override.example.com" {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+}
+
+func TestResolveAPIKeyCommandFailure(t *testing.T) {
+	p := Profile{APIKeyCommand: "exit 3"}
+	if _, err := p.ResolveAPIKey(context.Background()); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestNewClientFromProfile(t *testing.T) {
+	writeTestConfig(t, testConfig)
+	client, err := NewClientFromProfile("staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.endpoint != "https://staging.example.com" || client.apiKey != "staging-key" {
+		t.Errorf("unexpected client: %s %s", client.endpoint, client.apiKey)
+	}
+	client, err = NewClientFromProfile("default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.client.Timeout != 30*time.Second {
+		t.Errorf("expected timeout %v, got %v", 30*time.Second, client.client.Timeout)
+	}
+}
@@ -0,0 +1,225 @@
+package kaginawa
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeKind is the kind of a field change.
+type ChangeKind int
+
+// Change kinds.
+const (
+	Modified ChangeKind = iota
+	Added
+	Removed
+)
+
+// String returns the name of the kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case Modified:
+		return "modified"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a difference of a report field.
+// Set fields (usb_devices, bd_local_devices and errors) produce an Added or Removed change per element.
+type Change struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Kind is the kind of the change.
+	Kind ChangeKind
+
+	// Old is the previous value, empty if added.
+	Old string
+
+	// New is the current value, empty if removed.
+	New string
+}
+
+// String returns the change in human-readable form.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("%s: + %s", c.Field, c.New)
+	case Removed:
+		return fmt.Sprintf("%s: - %s", c.Field, c.Old)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", c.Field, quoteEmpty(c.Old), quoteEmpty(c.New))
+	}
+}
+
+// Changes is the list of changes between two reports.
+type Changes []Change
+
+// String returns the changes in human-readable form, one change per line.
+func (c Changes) String() string {
+	lines := make([]string, len(c))
+	for i, change := range c {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diffFields is the list of scalar fields compared by Diff. Measurements that change every report,
+// such as timestamps, RTT, throughput and disk usage, are excluded.
+var diffFields = []struct {
+	name  string
+	value func(Report) string
+}{
+	{"custom_id", func(r Report) string { return r.CustomID }},
+	{"hostname", func(r Report) string { return r.Hostname }},
+	{"runtime", func(r Report) string { return r.Runtime }},
+	{"kernel_version", func(r Report) string { return r.KernelVersion }},
+	{"agent_version", func(r Report) string { return r.AgentVersion }},
+	{"boot_time", func(r Report) string { return formatUnix(r.BootTime) }},
+	{"adapter", func(r Report) string { return r.Adapter }},
+	{"ip4_local", func(r Report) string { return r.LocalIPv4 }},
+	{"ip6_local", func(r Report) string { return r.LocalIPv6 }},
+	{"ip_global", func(r Report) string { return r.GlobalIP }},
+	{"host_global", func(r Report) string { return r.GlobalHost }},
+	{"ssh_server_host", func(r Report) string { return r.SSHServerHost }},
+	{"ssh_remote_port", func(r Report) string { return formatInt(int64(r.SSHRemotePort)) }},
+	{"ssh_connect_time", func(r Report) string { return formatUnix(r.SSHConnectTime) }},
+	{"disk_total_bytes", func(r Report) string { return formatInt(r.DiskTotalBytes) }},
+	{"disk_label", func(r Report) string { return r.DiskLabel }},
+	{"disk_filesystem", func(r Report) string { return r.DiskFilesystem }},
+	{"disk_mount_point", func(r Report) string { return r.DiskMountPoint }},
+	{"disk_device", func(r Report) string { return r.DiskDevice }},
+	{"payload_cmd", func(r Report) string { return r.PayloadCmd }},
+	{"success", func(r Report) string { return strconv.FormatBool(r.Success) }},
+}
+
+// Diff returns the changes from prev to cur.
+// USB devices are identified by vendor id, product id and location; a renamed device is reported as Modified.
+func Diff(prev, cur Report) Changes {
+	var changes Changes
+	for _, f := range diffFields {
+		if o, n := f.value(prev), f.value(cur); o != n {
+			changes = append(changes, Change{Field: f.name, Kind: Modified, Old: o, New: n})
+		}
+	}
+	changes = append(changes, diffSet("usb_devices", usbDeviceSet(prev.USBDevices), usbDeviceSet(cur.USBDevices))...)
+	changes = append(changes, diffSet("bd_local_devices", stringSet(prev.BDLocalDevices), stringSet(cur.BDLocalDevices))...)
+	changes = append(changes, diffSet("errors", stringSet(prev.Errors), stringSet(cur.Errors))...)
+	return changes
+}
+
+// ChangePoint is a report that differs from the preceding one.
+type ChangePoint struct {
+	// Time is the server time of the current report.
+	Time time.Time
+
+	// Previous is the preceding report.
+	Previous Report
+
+	// Current is the report that has changes.
+	Current Report
+
+	// Changes is the changes from Previous to Current.
+	Changes Changes
+}
+
+// HistoryChanges walks the histories of a node, such as the result of ListHistories, in order of ServerTime
+// and returns only the reports that differ from the preceding one.
+// Fields omitted by the projection of the server are empty on both sides, so they never appear as changes.
+func HistoryChanges(histories []Report) []ChangePoint {
+	histories = append([]Report(nil), histories...)
+	sort.SliceStable(histories, func(i, j int) bool { return histories[i].ServerTime < histories[j].ServerTime })
+	var points []ChangePoint
+	for i := 1; i < len(histories); i++ {
+		changes := Diff(histories[i-1], histories[i])
+		if len(changes) == 0 {
+			continue
+		}
+		points = append(points, ChangePoint{
+			Time:     histories[i].Timestamp(),
+			Previous: histories[i-1],
+			Current:  histories[i],
+			Changes:  changes,
+		})
+	}
+	return points
+}
+
+// diffSet compares the sets keyed by identity, with values used for display.
+func diffSet(field string, prev, cur map[string]string) Changes {
+	var changes Changes
+	for _, k := range sortedSetKeys(prev) {
+		n, ok := cur[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Field: field, Kind: Removed, Old: prev[k]})
+		case n != prev[k]:
+			changes = append(changes, Change{Field: field, Kind: Modified, Old: prev[k], New: n})
+		}
+	}
+	for _, k := range sortedSetKeys(cur) {
+		if _, ok := prev[k]; !ok {
+			changes = append(changes, Change{Field: field, Kind: Added, New: cur[k]})
+		}
+	}
+	return changes
+}
+
+func usbDeviceSet(devices []USBDevice) map[string]string {
+	set := make(map[string]string, len(devices))
+	for _, d := range devices {
+		key := d.VendorID + ":" + d.ProductID + "@" + d.Location
+		if len(d.Name) > 0 {
+			set[key] = key + " (" + d.Name + ")"
+		} else {
+			set[key] = key
+		}
+	}
+	return set
+}
+
+func stringSet(values []string) map[string]string {
+	set := make(map[string]string, len(values))
+	for _, v := range values {
+		set[v] = v
+	}
+	return set
+}
+
+func sortedSetKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatInt(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func formatUnix(v int64) string {
+	if v <= 0 {
+		return ""
+	}
+	return time.Unix(v, 0).UTC().Format(time.RFC3339)
+}
+
+func quoteEmpty(s string) string {
+	if len(s) == 0 {
+		return `""`
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package kaginawa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := Report{
+		ID:             "b8:27:eb:36:83:e0",
+		Hostname:       "test-rpi",
+		KernelVersion:  "5.4.51-v7l+",
+		LocalIPv4:      "192.168.1.10",
+		SSHServerHost:  "ssh1.example.com",
+		SSHRemotePort:  41383,
+		Success:        true,
+		ServerTime:     1587337308,
+		RTTMillis:      20,
+		DiskUsedBytes:  1000,
+		BDLocalDevices: []string{"B8:27:EB:00:00:01"},
+		USBDevices: []USBDevice{
+			{Name: "Logitech USB Receiver", VendorID: "046d", ProductID: "c52b", Location: "1-1.2"},
+			{Name: "Storage", VendorID: "0781", ProductID: "5583", Location: "1-1.3"},
+		},
+	}
+	new := old
+	new.KernelVersion = "5.10.17-v7l+"
+	new.LocalIPv4 = "192.168.1.23"
+	new.SSHServerHost = "ssh2.example.com"
+	new.Success = false
+	new.Errors = []string{"failed to measure throughput"}
+	new.ServerTime = 1587423708
+	new.RTTMillis = 35
+	new.DiskUsedBytes = 2000
+	new.BDLocalDevices = nil
+	new.USBDevices = []USBDevice{
+		{Name: "Logitech Unifying Receiver", VendorID: "046d", ProductID: "c52b", Location: "1-1.2"},
+		{Name: "FT232R", VendorID: "0403", ProductID: "6001", Location: "1-1.4"},
+	}
+
+	expected := Changes{
+		{Field: "kernel_version", Kind: Modified, Old: "5.4.51-v7l+", New: "5.10.17-v7l+"},
+		{Field: "ip4_local", Kind: Modified, Old: "192.168.1.10", New: "192.168.1.23"},
+		{Field: "ssh_server_host", Kind: Modified, Old: "ssh1.example.com", New: "ssh2.example.com"},
+		{Field: "success", Kind: Modified, Old: "true", New: "false"},
+		{Field: "usb_devices", Kind: Modified, Old: "046d:c52b@1-1.2 (Logitech USB Receiver)", New: "046d:c52b@1-1.2 (Logitech Unifying Receiver)"},
+		{Field: "usb_devices", Kind: Removed, Old: "0781:5583@1-1.3 (Storage)"},
+		{Field: "usb_devices", Kind: Added, New: "0403:6001@1-1.4 (FT232R)"},
+		{Field: "bd_local_devices", Kind: Removed, Old: "B8:27:EB:00:00:01"},
+		{Field: "errors", Kind: Added, New: "failed to measure throughput"},
+	}
+	actual := Diff(old, new)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestChangesString(t *testing.T) {
+	changes := Changes{
+		{Field: "kernel_version", Kind: Modified, Old: "5.4.51", New: "5.10.17"},
+		{Field: "ip6_local", Kind: Modified, Old: "", New: "fe80::1"},
+		{Field: "usb_devices", Kind: Added, New: "0403:6001@1-1.4"},
+		{Field: "errors", Kind: Removed, Old: "timeout"},
+	}
+	expected := "kernel_version: 5.4.51 -> 5.10.17\n" +
+		"ip6_local: \"\" -> fe80::1\n" +
+		"usb_devices: + 0403:6001@1-1.4\n" +
+		"errors: - timeout"
+	if actual := changes.String(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestHistoryChanges(t *testing.T) {
+	histories := []Report{
+		{ServerTime: 1587337908, AgentVersion: "v1.0.0", BootTime: 1587330000, RTTMillis: 10},
+		{ServerTime: 1587337308, AgentVersion: "v1.0.0", BootTime: 1587330000},
+		{ServerTime: 1587338508, AgentVersion: "v1.1.0", BootTime: 1587338500},
+		{ServerTime: 1587339108, AgentVersion: "v1.1.0", BootTime: 1587338500},
+	}
+	points := HistoryChanges(histories)
+	if len(points) != 1 {
+		t.Fatalf("expected %d change point, got %d", 1, len(points))
+	}
+	p := points[0]
+	if p.Time.Unix() != 1587338508 || p.Previous.ServerTime != 1587337908 || len(p.Changes) != 2 {
+		t.Errorf("unexpected change point: %+v", p)
+	}
+	if p.Changes[0].Field != "agent_version" || p.Changes[1].Field != "boot_time" {
+		t.Errorf("unexpected changes: %v", p.Changes)
+	}
+	if HistoryChanges(histories[:1]) != nil {
+		t.Error("expected nil for a single report")
+	}
+}
@@ -0,0 +1,171 @@
+package kaginawa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize is the upper limit of the error response body to be read.
+const maxErrorBodySize = 64 * 1024
+
+var (
+	// ErrNotFound is returned when the requested resource does not exist.
+	ErrNotFound = errors.New("kaginawa: not found")
+
+	// ErrUnauthorized is returned when the API key is missing or invalid.
+	ErrUnauthorized = errors.New("kaginawa: unauthorized")
+
+	// ErrForbidden is returned when the API key does not have enough privileges.
+	ErrForbidden = errors.New("kaginawa: forbidden")
+
+	// ErrCommandAuthFailed is returned when the node rejected the SSH credentials of the command.
+	ErrCommandAuthFailed = errors.New("kaginawa: command authentication failed")
+
+	// ErrCommandTimeout is returned when the command did not complete within the timeout.
+	ErrCommandTimeout = errors.New("kaginawa: command timed out")
+)
+
+// APIError represents an unexpected response of the Kaginawa Server.
+// Use errors.Is with the sentinel errors (ErrNotFound etc.) to classify the error.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Status is the HTTP status line of the response such as "404 Not Found".
+	Status string
+
+	// Method is the HTTP method of the request.
+	Method string
+
+	// URL is the request URL.
+	URL string
+
+	// RequestID is the value of the X-Request-Id response header, if any.
+	RequestID string
+
+	// Message is the error message decoded from the response body.
+	Message string
+
+	// Attempts is the number of attempts made including retries.
+	Attempts int
+
+	// Err is the sentinel error that classifies the response, or nil if unclassified.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("kaginawa server respond HTTP %s (%s %s)", e.Status, e.Method, e.URL)
+	if len(e.Message) > 0 {
+		msg += ": " + e.Message
+	}
+	if len(e.RequestID) > 0 {
+		msg += " [request-id: " + e.RequestID + "]"
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel error.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// RequestError is returned when no response was received, such as connection failures,
+// timeouts and cancellation of the context.
+type RequestError struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// URL is the request URL.
+	URL string
+
+	// Attempts is the number of attempts made including retries.
+	Attempts int
+
+	// Err is the cause of the failure.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RequestError) Error() string {
+	msg := fmt.Sprintf("failed to send request (%s %s): %v", e.Method, e.URL, e.Err)
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	return msg
+}
+
+// Unwrap returns the cause.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError builds an APIError from the unexpected response.
+// The response body will be consumed but not closed.
+func newAPIError(req *http.Request, resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Message:    decodeErrorMessage(resp.Body),
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		apiErr.Err = ErrNotFound
+	case http.StatusUnauthorized:
+		apiErr.Err = ErrUnauthorized
+	case http.StatusForbidden:
+		apiErr.Err = ErrForbidden
+	}
+	return apiErr
+}
+
+// decodeErrorMessage reads the error response body.
+// Both JSON ({"error": "..."} or {"message": "..."}) and plain text bodies are supported.
+func decodeErrorMessage(body io.Reader) string {
+	raw, err := ioutil.ReadAll(io.LimitReader(body, maxErrorBodySize))
+	if err != nil {
+		return ""
+	}
+	var decoded struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err == nil {
+		if len(decoded.Error) > 0 {
+			return decoded.Error
+		}
+		if len(decoded.Message) > 0 {
+			return decoded.Message
+		}
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// classifyCommandError attaches the command specific sentinel error to the APIError
+// unless the status code has already classified it (ErrNotFound, ErrUnauthorized or ErrForbidden).
+func classifyCommandError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Err != nil {
+		return err
+	}
+	msg := strings.ToLower(apiErr.Message)
+	switch {
+	case strings.Contains(msg, "authenticate"):
+		apiErr.Err = ErrCommandAuthFailed
+	case apiErr.StatusCode == http.StatusGatewayTimeout || apiErr.StatusCode == http.StatusRequestTimeout,
+		strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		apiErr.Err = ErrCommandTimeout
+	}
+	return apiErr
+}
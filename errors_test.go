@@ -0,0 +1,122 @@
+package kaginawa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorSentinels(t *testing.T) {
+	tests := []struct {
+		status   int
+		body     string
+		expected error
+		message  string
+	}{
+		{
+			status:   http.StatusNotFound,
+			body:     `{"error":"node not found"}`,
+			expected: ErrNotFound,
+			message:  "node not found",
+		},
+		{
+			status:   http.StatusUnauthorized,
+			body:     "invalid api key\n",
+			expected: ErrUnauthorized,
+			message:  "invalid api key",
+		},
+		{
+			status:   http.StatusForbidden,
+			body:     `{"message":"read only key"}`,
+			expected: ErrForbidden,
+			message:  "read only key",
+		},
+	}
+	for i, d := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-Id", "req-1")
+			w.WriteHeader(d.status)
+			if _, err := w.Write([]byte(d.body)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}))
+		client, err := NewClient(ts.URL, testAPIKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = client.FindNode(context.Background(), "f0:18:98:eb:c7:27")
+		ts.Close()
+		if !errors.Is(err, d.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, d.expected, err)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("test %d: expected *APIError, got %T", i, err)
+		}
+		if apiErr.StatusCode != d.status {
+			t.Errorf("test %d: StatusCode expected %d, got %d", i, d.status, apiErr.StatusCode)
+		}
+		if apiErr.Method != http.MethodGet {
+			t.Errorf("test %d: Method expected %s, got %s", i, http.MethodGet, apiErr.Method)
+		}
+		if apiErr.RequestID != "req-1" {
+			t.Errorf("test %d: RequestID expected %s, got %s", i, "req-1", apiErr.RequestID)
+		}
+		if apiErr.Message != d.message {
+			t.Errorf("test %d: Message expected %s, got %s", i, d.message, apiErr.Message)
+		}
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := []struct {
+		status   int
+		body     string
+		expected error
+	}{
+		{
+			status:   http.StatusInternalServerError,
+			body:     "ssh: handshake failed: ssh: unable to authenticate",
+			expected: ErrCommandAuthFailed,
+		},
+		{
+			status:   http.StatusGatewayTimeout,
+			body:     "",
+			expected: ErrCommandTimeout,
+		},
+		{
+			status:   http.StatusNotFound,
+			body:     "not found",
+			expected: ErrNotFound,
+		},
+		{
+			status:   http.StatusUnauthorized,
+			body:     "failed to authenticate api key",
+			expected: ErrUnauthorized,
+		},
+		{
+			status:   http.StatusForbidden,
+			body:     `{"error":"request timeout exceeds the limit of this key"}`,
+			expected: ErrForbidden,
+		},
+	}
+	for i, d := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(d.status)
+			if _, err := w.Write([]byte(d.body)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}))
+		client, err := NewClient(ts.URL, testAPIKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = client.Command(context.Background(), "f0:18:98:eb:c7:27", "uptime", "pi", "", "raspberry", 0)
+		ts.Close()
+		if !errors.Is(err, d.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, d.expected, err)
+		}
+	}
+}
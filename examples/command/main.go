@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -53,7 +54,7 @@ func main() {
 		result, err := client.Command(context.Background(), id, command, user, "", password, 0)
 		if err != nil {
 			println(err.Error())
-			if strings.Contains(err.Error(), "authenticate") {
+			if errors.Is(err, kaginawa.ErrCommandAuthFailed) {
 				os.Exit(1)
 			}
 		} else {
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// Column is a column of the CSV output.
+type Column struct {
+	// Name is the header of the column.
+	Name string
+
+	// Value formats the field of the report.
+	Value func(r kaginawa.Report) string
+}
+
+// AllColumns is the list of all available columns, named after the JSON fields of the report
+// except upload_kbps and download_kbps, whose JSON fields are upload_bps and download_bps.
+// The usb_devices column flattens devices as "vendor:product@location name" separated by semicolons.
+var AllColumns = []Column{
+	{"id", func(r kaginawa.Report) string { return r.ID }},
+	{"custom_id", func(r kaginawa.Report) string { return r.CustomID }},
+	{"hostname", func(r kaginawa.Report) string { return r.Hostname }},
+	{"trigger", func(r kaginawa.Report) string { return strconv.Itoa(r.Trigger) }},
+	{"runtime", func(r kaginawa.Report) string { return r.Runtime }},
+	{"success", func(r kaginawa.Report) string { return strconv.FormatBool(r.Success) }},
+	{"seq", func(r kaginawa.Report) string { return strconv.Itoa(r.Sequence) }},
+	{"device_time", func(r kaginawa.Report) string { return formatUnix(r.DeviceTime) }},
+	{"boot_time", func(r kaginawa.Report) string { return formatUnix(r.BootTime) }},
+	{"server_time", func(r kaginawa.Report) string { return formatUnix(r.ServerTime) }},
+	{"gen_ms", func(r kaginawa.Report) string { return strconv.FormatInt(r.GenMillis, 10) }},
+	{"agent_version", func(r kaginawa.Report) string { return r.AgentVersion }},
+	{"kernel_version", func(r kaginawa.Report) string { return r.KernelVersion }},
+	{"ssh_server_host", func(r kaginawa.Report) string { return r.SSHServerHost }},
+	{"ssh_remote_port", func(r kaginawa.Report) string { return strconv.Itoa(r.SSHRemotePort) }},
+	{"ssh_connect_time", func(r kaginawa.Report) string { return formatUnix(r.SSHConnectTime) }},
+	{"adapter", func(r kaginawa.Report) string { return r.Adapter }},
+	{"ip4_local", func(r kaginawa.Report) string { return r.LocalIPv4 }},
+	{"ip6_local", func(r kaginawa.Report) string { return r.LocalIPv6 }},
+	{"ip_global", func(r kaginawa.Report) string { return r.GlobalIP }},
+	{"host_global", func(r kaginawa.Report) string { return r.GlobalHost }},
+	{"rtt_ms", func(r kaginawa.Report) string { return strconv.FormatInt(r.RTTMillis, 10) }},
+	{"upload_kbps", func(r kaginawa.Report) string { return strconv.FormatInt(r.UploadKBPS, 10) }},
+	{"download_kbps", func(r kaginawa.Report) string { return strconv.FormatInt(r.DownloadKBPS, 10) }},
+	{"disk_total_bytes", func(r kaginawa.Report) string { return strconv.FormatInt(r.DiskTotalBytes, 10) }},
+	{"disk_used_bytes", func(r kaginawa.Report) string { return strconv.FormatInt(r.DiskUsedBytes, 10) }},
+	{"disk_label", func(r kaginawa.Report) string { return r.DiskLabel }},
+	{"disk_filesystem", func(r kaginawa.Report) string { return r.DiskFilesystem }},
+	{"disk_mount_point", func(r kaginawa.Report) string { return r.DiskMountPoint }},
+	{"disk_device", func(r kaginawa.Report) string { return r.DiskDevice }},
+	{"usb_device_count", func(r kaginawa.Report) string { return strconv.Itoa(len(r.USBDevices)) }},
+	{"usb_devices", func(r kaginawa.Report) string { return formatUSBDevices(r.USBDevices) }},
+	{"bd_local_devices", func(r kaginawa.Report) string { return strings.Join(r.BDLocalDevices, ";") }},
+	{"errors", func(r kaginawa.Report) string { return strings.Join(r.Errors, ";") }},
+	{"payload_cmd", func(r kaginawa.Report) string { return r.PayloadCmd }},
+	{"payload", func(r kaginawa.Report) string { return r.Payload }},
+}
+
+// DefaultColumns is the list of columns used when none are specified.
+var DefaultColumns = mustColumns("id", "custom_id", "hostname", "server_time", "success",
+	"rtt_ms", "upload_kbps", "download_kbps", "disk_used_bytes", "disk_total_bytes", "gen_ms")
+
+// Columns looks up the columns by name.
+func Columns(names ...string) ([]Column, error) {
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, c := range AllColumns {
+			if c.Name == name {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+	}
+	return columns, nil
+}
+
+func mustColumns(names ...string) []Column {
+	columns, err := Columns(names...)
+	if err != nil {
+		panic(err)
+	}
+	return columns
+}
+
+// CSVEncoder writes reports as CSV with a header line.
+type CSVEncoder struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool
+	record  []string
+}
+
+// NewCSVEncoder creates a CSV encoder. Nil columns means DefaultColumns.
+func NewCSVEncoder(w io.Writer, columns []Column) *CSVEncoder {
+	if columns == nil {
+		columns = DefaultColumns
+	}
+	return &CSVEncoder{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+}
+
+// Encode writes the report as a CSV record.
+func (e *CSVEncoder) Encode(r kaginawa.Report) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for i, c := range e.columns {
+		e.record[i] = c.Value(r)
+	}
+	return e.w.Write(e.record)
+}
+
+// Flush writes the header if nothing was written, and flushes buffered records.
+func (e *CSVEncoder) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *CSVEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	names := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		names[i] = c.Name
+	}
+	return e.w.Write(names)
+}
+
+func formatUSBDevices(devices []kaginawa.USBDevice) string {
+	values := make([]string, len(devices))
+	for i, d := range devices {
+		values[i] = strings.TrimSpace(d.VendorID + ":" + d.ProductID + "@" + d.Location + " " + d.Name)
+	}
+	return strings.Join(values, ";")
+}
+
+func formatUnix(v int64) string {
+	if v <= 0 {
+		return ""
+	}
+	return time.Unix(v, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+var testReports = []kaginawa.Report{
+	{
+		ID:             "b8:27:eb:36:83:e0",
+		CustomID:       "test-rpi",
+		Hostname:       "test-rpi.local",
+		Success:        true,
+		ServerTime:     1587337308,
+		RTTMillis:      23,
+		UploadKBPS:     1500,
+		DownloadKBPS:   25000,
+		DiskTotalBytes: 32000000000,
+		DiskUsedBytes:  8000000000,
+		GenMillis:      15,
+		USBDevices: []kaginawa.USBDevice{
+			{Name: "Logitech USB Receiver", VendorID: "046d", ProductID: "c52b", Location: "1-1.2"},
+			{VendorID: "0403", ProductID: "6001", Location: "1-1.4"},
+		},
+	},
+	{
+		ID:         "f0:18:98:eb:c7:27",
+		CustomID:   "mac, office",
+		Hostname:   "test mac",
+		ServerTime: 1587337908,
+		GenMillis:  8,
+		Errors:     []string{"no network", "no disk"},
+	},
+}
+
+func TestCSVEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeAll(NewCSVEncoder(&buf, nil), testReports); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "id,custom_id,hostname,server_time,success,rtt_ms,upload_kbps,download_kbps,disk_used_bytes,disk_total_bytes,gen_ms\n" +
+		"b8:27:eb:36:83:e0,test-rpi,test-rpi.local,2020-04-19T23:01:48Z,true,23,1500,25000,8000000000,32000000000,15\n" +
+		"f0:18:98:eb:c7:27,\"mac, office\",test mac,2020-04-19T23:11:48Z,false,0,0,0,0,0,8\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestCSVEncoderColumns(t *testing.T) {
+	columns, err := Columns("id", "usb_device_count", "usb_devices", "errors")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := EncodeAll(NewCSVEncoder(&buf, columns), testReports); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "id,usb_device_count,usb_devices,errors\n" +
+		"b8:27:eb:36:83:e0,2,046d:c52b@1-1.2 Logitech USB Receiver;0403:6001@1-1.4,\n" +
+		"f0:18:98:eb:c7:27,0,,no network;no disk\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+	if _, err := Columns("id", "unknown"); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestCSVEncoderEmpty(t *testing.T) {
+	columns, _ := Columns("id", "hostname")
+	var buf bytes.Buffer
+	if err := EncodeAll(NewCSVEncoder(&buf, columns), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "id,hostname\n" {
+		t.Errorf("expected header only, got %q", buf.String())
+	}
+}
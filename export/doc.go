@@ -0,0 +1,4 @@
+// Package export encodes reports as CSV, JSON Lines or InfluxDB line protocol for bulk export.
+// Encoders stream to an io.Writer one report at a time, so exporting long histories does not
+// hold them in memory. For live Prometheus metrics, see package exporter.
+package export
@@ -0,0 +1,34 @@
+package export
+
+import (
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// Encoder writes reports one by one. Flush must be called after the last report.
+type Encoder interface {
+	Encode(r kaginawa.Report) error
+	Flush() error
+}
+
+// EncodeAll writes the reports and flushes the encoder.
+func EncodeAll(enc Encoder, reports []kaginawa.Report) error {
+	for _, r := range reports {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+// EncodeIterator writes all reports of the iterator and flushes the encoder.
+func EncodeIterator(enc Encoder, it kaginawa.ReportIterator) error {
+	for it.Next() {
+		if err := enc.Encode(it.Report()); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
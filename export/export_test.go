@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kaginawa/kaginawa-sdk-go/kaginawatest"
+)
+
+func TestJSONLinesEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeAll(NewJSONLinesEncoder(&buf), testReports); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scanner := bufio.NewScanner(&buf)
+	n := 0
+	for scanner.Scan() {
+		var r struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %d: failed to decode: %v", n, err)
+		}
+		if r.ID != testReports[n].ID {
+			t.Errorf("line %d: expected id %s, got %s", n, testReports[n].ID, r.ID)
+		}
+		n++
+	}
+	if n != len(testReports) {
+		t.Errorf("expected %d lines, got %d", len(testReports), n)
+	}
+}
+
+func TestEncodeIterator(t *testing.T) {
+	s := kaginawatest.NewServer()
+	defer s.Close()
+	for _, r := range testReports {
+		r.ID = testReports[0].ID
+		s.AddReport(r)
+	}
+	client, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	columns, _ := Columns("id", "server_time")
+	var buf bytes.Buffer
+	it := client.Histories(context.Background(), testReports[0].ID, 1587337300, 1587338000, 0)
+	defer it.Close()
+	if err := EncodeIterator(NewCSVEncoder(&buf, columns), it); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Reports are written in ascending order of server time.
+	expected := "id,server_time\n" +
+		"b8:27:eb:36:83:e0,2020-04-19T23:01:48Z\n" +
+		"b8:27:eb:36:83:e0,2020-04-19T23:11:48Z\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// Iterator errors are returned.
+	it = client.Histories(context.Background(), testReports[0].ID, 0, 0, 0)
+	if err := EncodeIterator(NewCSVEncoder(&buf, columns), it); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	"github.com/kaginawa/kaginawa-sdk-go/series"
+)
+
+// InfluxEncoder writes reports as InfluxDB line protocol.
+// Each metric becomes a measurement with an integer field "value", tagged by id, custom_id and hostname,
+// and timestamped by ServerTime in nanoseconds:
+//
+//	rtt_ms,id=b8:27:eb:36:83:e0,custom_id=test-rpi,hostname=test-rpi.local value=23i 1587337308000000000
+//
+// Unmeasured metrics, such as zero RTT, are skipped.
+type InfluxEncoder struct {
+	// Prefix is prepended to the measurement names, such as "kaginawa_".
+	Prefix string
+
+	// Metrics is the list of metrics to write. Nil means series.DefaultMetrics.
+	Metrics []series.Metric
+
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewInfluxEncoder creates an InfluxDB line protocol encoder.
+func NewInfluxEncoder(w io.Writer) *InfluxEncoder {
+	return &InfluxEncoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes a line per metric of the report. Reports without ServerTime are skipped.
+func (e *InfluxEncoder) Encode(r kaginawa.Report) error {
+	if r.ServerTime <= 0 {
+		return nil
+	}
+	metrics := e.Metrics
+	if metrics == nil {
+		metrics = series.DefaultMetrics
+	}
+	tags := influxTags("id", r.ID, "custom_id", r.CustomID, "hostname", r.Hostname)
+	timestamp := strconv.FormatInt(r.ServerTime, 10) + "000000000"
+	for _, m := range metrics {
+		v, ok := m.Value(r)
+		if !ok {
+			continue
+		}
+		e.buf = e.buf[:0]
+		e.buf = append(e.buf, measurementEscaper.Replace(e.Prefix+string(m))...)
+		e.buf = append(e.buf, tags...)
+		e.buf = append(e.buf, " value="...)
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+		e.buf = append(e.buf, "i "...)
+		e.buf = append(e.buf, timestamp...)
+		e.buf = append(e.buf, '\n')
+		if _, err := e.w.Write(e.buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush flushes buffered lines.
+func (e *InfluxEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+// influxTags renders the name-value pairs as ",name=value,...". Empty values are omitted.
+func influxTags(pairs ...string) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if len(pairs[i+1]) == 0 {
+			continue
+		}
+		sb.WriteByte(',')
+		sb.WriteString(pairs[i])
+		sb.WriteByte('=')
+		sb.WriteString(tagEscaper.Replace(pairs[i+1]))
+	}
+	return sb.String()
+}
+
+// Escapers of the line protocol special characters.
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	"github.com/kaginawa/kaginawa-sdk-go/series"
+)
+
+func TestInfluxEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewInfluxEncoder(&buf)
+	reports := append(testReports, kaginawa.Report{ID: "no-server-time", GenMillis: 1})
+	if err := EncodeAll(enc, reports); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags := ",id=b8:27:eb:36:83:e0,custom_id=test-rpi,hostname=test-rpi.local"
+	expected := "rtt_ms" + tags + " value=23i 1587337308000000000\n" +
+		"upload_kbps" + tags + " value=1500i 1587337308000000000\n" +
+		"download_kbps" + tags + " value=25000i 1587337308000000000\n" +
+		"disk_used_bytes" + tags + " value=8000000000i 1587337308000000000\n" +
+		"gen_ms" + tags + " value=15i 1587337308000000000\n" +
+		`gen_ms,id=f0:18:98:eb:c7:27,custom_id=mac\,\ office,hostname=test\ mac value=8i 1587337908000000000` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestInfluxEncoderOptions(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewInfluxEncoder(&buf)
+	enc.Prefix = "kaginawa_"
+	enc.Metrics = []series.Metric{series.RTTMillis}
+	if err := EncodeAll(enc, []kaginawa.Report{{ID: "b8:27:eb:36:83:e0", RTTMillis: 5, ServerTime: 1587337308}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "kaginawa_rtt_ms,id=b8:27:eb:36:83:e0 value=5i 1587337308000000000\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
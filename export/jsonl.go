@@ -0,0 +1,31 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// JSONLinesEncoder writes reports as JSON Lines, one JSON object per line.
+type JSONLinesEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLinesEncoder creates a JSON Lines encoder.
+func NewJSONLinesEncoder(w io.Writer) *JSONLinesEncoder {
+	bw := bufio.NewWriter(w)
+	return &JSONLinesEncoder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Encode writes the report as a line.
+func (e *JSONLinesEncoder) Encode(r kaginawa.Report) error {
+	return e.enc.Encode(r)
+}
+
+// Flush flushes buffered lines.
+func (e *JSONLinesEncoder) Flush() error {
+	return e.w.Flush()
+}
@@ -0,0 +1,2 @@
+// Package exporter serves the latest reports of nodes as Prometheus metrics in the text exposition format.
+package exporter
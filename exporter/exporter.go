@@ -0,0 +1,223 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// Defaults of Exporter.
+const (
+	DefaultNamespace   = "kaginawa"
+	DefaultCacheTTL    = 30 * time.Second
+	DefaultAliveWindow = 5 * time.Minute
+	DefaultTimeout     = 10 * time.Second
+)
+
+// contentType is the content type of the text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter is an http.Handler that serves per-node gauges.
+// Nodes are queried on scrape and cached for CacheTTL, so concurrent or frequent scrapes share one query.
+type Exporter struct {
+	// Client is the Kaginawa client.
+	Client *kaginawa.Client
+
+	// Query is the node query of each scrape. Zero value lists all nodes.
+	Query kaginawa.NodeQuery
+
+	// Namespace is the prefix of metric names. Empty means DefaultNamespace.
+	Namespace string
+
+	// CacheTTL is the lifetime of the cached nodes. Zero means DefaultCacheTTL; negative disables the cache.
+	CacheTTL time.Duration
+
+	// AliveWindow is the age of the last report to be treated as up. Zero means DefaultAliveWindow.
+	AliveWindow time.Duration
+
+	// Timeout is the time limit of querying nodes. Zero means DefaultTimeout.
+	Timeout time.Duration
+
+	// Now returns the current time. Nil means time.Now.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	cached    []kaginawa.Report
+	fetchedAt time.Time
+}
+
+// New creates an exporter with defaults.
+func New(client *kaginawa.Client) *Exporter {
+	return &Exporter{Client: client}
+}
+
+// ServeHTTP queries nodes (or uses the cache) and writes the metrics.
+// If the query fails, only the exporter metrics are written with <namespace>_up 0.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := e.now()
+	reports, err := e.reports(r.Context())
+	var buf bytes.Buffer
+	e.write(&buf, reports, err == nil, e.now().Sub(start))
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// reports returns the cached nodes, or queries them if the cache is expired.
+func (e *Exporter) reports(ctx context.Context) ([]kaginawa.Report, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ttl := e.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	if ttl > 0 && !e.fetchedAt.IsZero() && e.now().Sub(e.fetchedAt) < ttl {
+		return e.cached, nil
+	}
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	reports, err := e.Client.ListNodes(ctx, e.Query)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID < reports[j].ID })
+	e.cached = reports
+	e.fetchedAt = e.now()
+	return reports, nil
+}
+
+func (e *Exporter) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now()
+}
+
+// node is the report with the state derived at the scrape time.
+type node struct {
+	kaginawa.Report
+	age   time.Duration
+	alive bool
+}
+
+// gauge is a per-node metric. The value function returns false to omit the sample, such as unmeasured values.
+type gauge struct {
+	name  string
+	help  string
+	value func(n node) (float64, bool)
+}
+
+var gauges = []gauge{
+	{"node_up", "Whether the node reported within the alive window.",
+		func(n node) (float64, bool) { return boolValue(n.alive), true }},
+	{"node_last_seen_seconds", "Seconds since the last report.",
+		func(n node) (float64, bool) { return n.age.Seconds(), true }},
+	{"node_rtt_ms", "Measured round trip time in milliseconds.",
+		func(n node) (float64, bool) { return float64(n.RTTMillis), n.RTTMillis > 0 }},
+	{"node_upload_kbps", "Measured upload throughput in kbps.",
+		func(n node) (float64, bool) { return float64(n.UploadKBPS), n.UploadKBPS > 0 }},
+	{"node_download_kbps", "Measured download throughput in kbps.",
+		func(n node) (float64, bool) { return float64(n.DownloadKBPS), n.DownloadKBPS > 0 }},
+	{"node_disk_used_bytes", "Used disk space in bytes.",
+		func(n node) (float64, bool) { return float64(n.DiskUsedBytes), n.DiskTotalBytes > 0 }},
+	{"node_disk_total_bytes", "Total disk space in bytes.",
+		func(n node) (float64, bool) { return float64(n.DiskTotalBytes), n.DiskTotalBytes > 0 }},
+	{"node_gen_ms", "Report generation time in milliseconds.",
+		func(n node) (float64, bool) { return float64(n.GenMillis), true }},
+	{"node_seq", "Number of reports generated since the kaginawa process start.",
+		func(n node) (float64, bool) { return float64(n.Sequence), true }},
+	{"node_ssh_connected", "Whether the node is connected to an SSH server.",
+		func(n node) (float64, bool) { return boolValue(n.SSHConnected()), true }},
+}
+
+// write renders the metrics in the text exposition format.
+func (e *Exporter) write(buf *bytes.Buffer, reports []kaginawa.Report, up bool, duration time.Duration) {
+	ns := e.Namespace
+	if len(ns) == 0 {
+		ns = DefaultNamespace
+	}
+	window := e.AliveWindow
+	if window <= 0 {
+		window = DefaultAliveWindow
+	}
+	writeHeader(buf, ns+"_up", "Whether the last query to Kaginawa Server succeeded.")
+	fmt.Fprintf(buf, "%s_up %s\n", ns, formatValue(boolValue(up)))
+	writeHeader(buf, ns+"_scrape_duration_seconds", "Time spent to collect the metrics.")
+	fmt.Fprintf(buf, "%s_scrape_duration_seconds %s\n", ns, formatValue(duration.Seconds()))
+	if !up {
+		return
+	}
+	now := e.now()
+	nodes := make([]node, len(reports))
+	labels := make([]string, len(reports))
+	for i, r := range reports {
+		age := now.Sub(r.Timestamp())
+		if age < 0 {
+			age = 0
+		}
+		nodes[i] = node{Report: r, age: age, alive: age <= window}
+		labels[i] = formatLabels(
+			"id", r.ID,
+			"custom_id", r.CustomID,
+			"hostname", r.Hostname,
+			"runtime", r.Runtime,
+			"agent_version", r.AgentVersion,
+		)
+	}
+	for _, g := range gauges {
+		name := ns + "_" + g.name
+		writeHeader(buf, name, g.help)
+		for i, n := range nodes {
+			if v, ok := g.value(n); ok {
+				fmt.Fprintf(buf, "%s%s %s\n", name, labels[i], formatValue(v))
+			}
+		}
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, name, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", name)
+}
+
+// formatLabels renders the name-value pairs as {name="value",...}.
+func formatLabels(pairs ...string) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(pairs[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(pairs[i+1]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// labelEscaper escapes label values as defined by the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
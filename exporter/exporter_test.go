@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	"github.com/kaginawa/kaginawa-sdk-go/kaginawatest"
+)
+
+var testNow = time.Unix(1587337308, 0)
+
+func newTestExporter(t *testing.T) (*Exporter, *kaginawatest.Server) {
+	s := kaginawatest.NewServer()
+	t.Cleanup(s.Close)
+	s.AddReport(kaginawa.Report{
+		ID:             "b8:27:eb:36:83:e0",
+		CustomID:       "test-rpi",
+		Hostname:       "test-rpi.local",
+		Runtime:        "linux arm",
+		AgentVersion:   "v1.0.0",
+		Sequence:       42,
+		GenMillis:      15,
+		RTTMillis:      23,
+		UploadKBPS:     1500,
+		DownloadKBPS:   25000,
+		DiskTotalBytes: 32000000000,
+		DiskUsedBytes:  8000000000,
+		SSHServerHost:  "example.com",
+		SSHRemotePort:  41383,
+		ServerTime:     testNow.Unix() - 30,
+	})
+	s.AddReport(kaginawa.Report{
+		ID:           "f0:18:98:eb:c7:27",
+		CustomID:     `quoted "mac"`,
+		Hostname:     "test-mac.local",
+		Runtime:      "darwin amd64",
+		AgentVersion: "v1.0.0",
+		ServerTime:   testNow.Unix() - 3600,
+	})
+	client, err := s.NewClient(kaginawa.WithRetryPolicy(kaginawa.NoRetry()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := New(client)
+	e.Now = func() time.Time { return testNow }
+	return e, s
+}
+
+func scrape(t *testing.T, h http.Handler) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("expected content type %s, got %s", contentType, ct)
+	}
+	body, _ := ioutil.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestExporter(t *testing.T) {
+	e, _ := newTestExporter(t)
+	body := scrape(t, e)
+	rpi := `{id="b8:27:eb:36:83:e0",custom_id="test-rpi",hostname="test-rpi.local",runtime="linux arm",agent_version="v1.0.0"}`
+	mac := `{id="f0:18:98:eb:c7:27",custom_id="quoted \"mac\"",hostname="test-mac.local",runtime="darwin amd64",agent_version="v1.0.0"}`
+	expected := []string{
+		"kaginawa_up 1\n",
+		"# HELP kaginawa_node_up Whether the node reported within the alive window.\n# TYPE kaginawa_node_up gauge\n",
+		"kaginawa_node_up" + rpi + " 1\n",
+		"kaginawa_node_up" + mac + " 0\n",
+		"kaginawa_node_last_seen_seconds" + rpi + " 30\n",
+		"kaginawa_node_last_seen_seconds" + mac + " 3600\n",
+		"kaginawa_node_rtt_ms" + rpi + " 23\n",
+		"kaginawa_node_upload_kbps" + rpi + " 1500\n",
+		"kaginawa_node_download_kbps" + rpi + " 25000\n",
+		"kaginawa_node_disk_used_bytes" + rpi + " 8e+09\n",
+		"kaginawa_node_disk_total_bytes" + rpi + " 3.2e+10\n",
+		"kaginawa_node_gen_ms" + rpi + " 15\n",
+		"kaginawa_node_seq" + rpi + " 42\n",
+		"kaginawa_node_ssh_connected" + rpi + " 1\n",
+		"kaginawa_node_ssh_connected" + mac + " 0\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected %q in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "kaginawa_node_rtt_ms"+mac) {
+		t.Errorf("expected no rtt of unmeasured node:\n%s", body)
+	}
+	if strings.Index(body, rpi) > strings.Index(body, mac) {
+		t.Errorf("expected nodes sorted by id:\n%s", body)
+	}
+}
+
+func TestExporterCache(t *testing.T) {
+	e, s := newTestExporter(t)
+	now := testNow
+	e.Now = func() time.Time { return now }
+	scrape(t, e)
+	scrape(t, e)
+	if n := len(s.Requests()); n != 1 {
+		t.Errorf("expected %d request, got %d", 1, n)
+	}
+	now = now.Add(DefaultCacheTTL)
+	scrape(t, e)
+	if n := len(s.Requests()); n != 2 {
+		t.Errorf("expected %d requests, got %d", 2, n)
+	}
+
+	e.CacheTTL = -1
+	scrape(t, e)
+	if n := len(s.Requests()); n != 3 {
+		t.Errorf("expected %d requests, got %d", 3, n)
+	}
+}
+
+func TestExporterQueryFailure(t *testing.T) {
+	e, s := newTestExporter(t)
+	e.Namespace = "fleet"
+	s.SetAuthFailure(true)
+	body := scrape(t, e)
+	if !strings.Contains(body, "fleet_up 0\n") {
+		t.Errorf("expected fleet_up 0 in:\n%s", body)
+	}
+	if strings.Contains(body, "fleet_node_up") {
+		t.Errorf("expected no node metrics in:\n%s", body)
+	}
+
+	// Failures are not cached.
+	s.SetAuthFailure(false)
+	if body := scrape(t, e); !strings.Contains(body, "fleet_up 1\n") {
+		t.Errorf("expected fleet_up 1 in:\n%s", body)
+	}
+}
@@ -0,0 +1,226 @@
+package kaginawa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultFleetConcurrency is the default number of concurrent commands of CommandMany.
+const DefaultFleetConcurrency = 4
+
+// OutcomeStatus classifies the outcome of the command on a node.
+type OutcomeStatus int
+
+const (
+	// OutcomeSucceeded means the command was executed.
+	OutcomeSucceeded OutcomeStatus = iota
+
+	// OutcomeFailed means the command submission failed.
+	OutcomeFailed
+
+	// OutcomeUnreachable means the node is not connected to any SSH server (no ssh_remote_port).
+	OutcomeUnreachable
+
+	// OutcomeSkipped means the command was not executed because of the fail-fast mode or cancellation.
+	OutcomeSkipped
+)
+
+// String returns the name of the status.
+func (s OutcomeStatus) String() string {
+	switch s {
+	case OutcomeSucceeded:
+		return "succeeded"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeUnreachable:
+		return "unreachable"
+	case OutcomeSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// NodeOutcome is the outcome of the command on a node.
+type NodeOutcome struct {
+	NodeID string
+	Status OutcomeStatus
+	Result *CommandResult
+	Err    error
+}
+
+// FleetOptions configures CommandMany.
+type FleetOptions struct {
+	// Concurrency is the maximum number of concurrent commands. Zero means DefaultFleetConcurrency.
+	Concurrency int
+
+	// NodeTimeout is the time limit of the request for each node. Zero means no limit.
+	NodeTimeout time.Duration
+
+	// FailFast stops submitting commands after the first failure.
+	FailFast bool
+
+	// Progress is called after each node has finished. Calls are serialized.
+	Progress func(outcome NodeOutcome, done, total int)
+}
+
+// FleetResult is the aggregated result of CommandMany keyed by node ID.
+type FleetResult struct {
+	// Succeeded holds results of the nodes that executed the command, regardless of the exit status.
+	// Check ExitCode and ExitCodeKnown of each result for the outcome of the command itself.
+	Succeeded map[string]*CommandResult
+
+	// Failed holds errors of the nodes that the command submission failed.
+	Failed map[string]error
+
+	// Unreachable holds reports of the nodes that are not connected to any SSH server.
+	Unreachable map[string]Report
+
+	// Skipped holds IDs of the nodes that the command was not submitted.
+	Skipped []string
+}
+
+func (r *FleetResult) add(outcome NodeOutcome, node Report) {
+	switch outcome.Status {
+	case OutcomeSucceeded:
+		r.Succeeded[outcome.NodeID] = outcome.Result
+	case OutcomeFailed:
+		r.Failed[outcome.NodeID] = outcome.Err
+	case OutcomeUnreachable:
+		r.Unreachable[outcome.NodeID] = node
+	case OutcomeSkipped:
+		r.Skipped = append(r.Skipped, outcome.NodeID)
+	}
+}
+
+// CommandMany submits the same command to the nodes in parallel.
+// Nodes without ssh_remote_port are reported as unreachable without submitting the command.
+// With SSHServerCredential, each SSH server entry is looked up once per distinct host of the given reports.
+// In fail-fast mode, the first failure is returned as the error together with the partial result.
+func (c *Client) CommandMany(ctx context.Context, nodes []Report, req CommandRequest, opts FleetOptions) (*FleetResult, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultFleetConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	result := &FleetResult{
+		Succeeded:   map[string]*CommandResult{},
+		Failed:      map[string]error{},
+		Unreachable: map[string]Report{},
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		done     int
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+	record := func(outcome NodeOutcome, node Report) {
+		mu.Lock()
+		defer mu.Unlock()
+		if outcome.Status == OutcomeFailed && opts.FailFast {
+			if firstErr != nil && errors.Is(outcome.Err, context.Canceled) {
+				outcome.Status = OutcomeSkipped
+			} else if firstErr == nil {
+				firstErr = outcome.Err
+				cancel()
+			}
+		}
+		result.add(outcome, node)
+		done++
+		if opts.Progress != nil {
+			opts.Progress(outcome, done, len(nodes))
+		}
+	}
+	var servers map[string]serverLookup
+	if _, ok := req.Credential.(SSHServerCredential); ok {
+		servers = c.lookupSSHServers(ctx, nodes)
+	}
+	for _, node := range nodes {
+		if node.SSHRemotePort <= 0 {
+			record(NodeOutcome{NodeID: node.ID, Status: OutcomeUnreachable}, node)
+			continue
+		}
+		nodeReq := req
+		if servers != nil {
+			lookup := servers[node.SSHServerHost]
+			if lookup.err != nil {
+				record(NodeOutcome{NodeID: node.ID, Status: OutcomeFailed, Err: lookup.err}, node)
+				continue
+			}
+			nodeReq.Credential = resolvedServerCredential{server: lookup.server}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			record(NodeOutcome{NodeID: node.ID, Status: OutcomeSkipped, Err: ctx.Err()}, node)
+			continue
+		}
+		wg.Add(1)
+		go func(node Report, req CommandRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			nodeCtx := ctx
+			if opts.NodeTimeout > 0 {
+				var nodeCancel context.CancelFunc
+				nodeCtx, nodeCancel = context.WithTimeout(ctx, opts.NodeTimeout)
+				defer nodeCancel()
+			}
+			r, err := c.RunCommand(nodeCtx, node.ID, req)
+			if err != nil {
+				record(NodeOutcome{NodeID: node.ID, Status: OutcomeFailed, Err: err}, node)
+				return
+			}
+			record(NodeOutcome{NodeID: node.ID, Status: OutcomeSucceeded, Result: r}, node)
+		}(node, nodeReq)
+	}
+	wg.Wait()
+	sort.Strings(result.Skipped)
+	return result, firstErr
+}
+
+// serverLookup is the result of looking up an SSH server entry.
+type serverLookup struct {
+	server SSHServer
+	err    error
+}
+
+// lookupSSHServers looks up the SSH server entries of the reachable nodes once per distinct host.
+func (c *Client) lookupSSHServers(ctx context.Context, nodes []Report) map[string]serverLookup {
+	servers := map[string]serverLookup{}
+	for _, node := range nodes {
+		if node.SSHRemotePort <= 0 {
+			continue
+		}
+		if _, ok := servers[node.SSHServerHost]; ok {
+			continue
+		}
+		if len(node.SSHServerHost) == 0 {
+			servers[node.SSHServerHost] = serverLookup{err: errors.New("node is not connected to any ssh server")}
+			continue
+		}
+		server, err := c.FindSSHServerByHostname(ctx, node.SSHServerHost)
+		if err != nil {
+			servers[node.SSHServerHost] = serverLookup{err: fmt.Errorf("failed to find ssh server: %w", err)}
+			continue
+		}
+		servers[node.SSHServerHost] = serverLookup{server: *server}
+	}
+	return servers
+}
+
+// CommandByCustomID submits the same command to all nodes that share the custom-id in parallel.
+func (c *Client) CommandByCustomID(ctx context.Context, customID string, req CommandRequest, opts FleetOptions) (*FleetResult, error) {
+	nodes, err := c.ListNodesByCustomID(ctx, customID)
+	if err != nil {
+		return nil, err
+	}
+	return c.CommandMany(ctx, nodes, req, opts)
+}
@@ -0,0 +1,190 @@
+package kaginawa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newFleetServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			t.Errorf("failed to parse form: %v", err)
+			return
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/nodes/"), "/command")
+		if strings.HasPrefix(id, "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if strings.HasPrefix(id, "slow") {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("hello from " + id)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestCommandMany(t *testing.T) {
+	var requests int32
+	ts := newFleetServer(t, &requests)
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodes := []Report{
+		{ID: "ok1", SSHRemotePort: 10001},
+		{ID: "ok2", SSHRemotePort: 10002},
+		{ID: "fail1", SSHRemotePort: 10003},
+		{ID: "offline1"},
+	}
+	var progress int32
+	result, err := client.CommandMany(context.Background(), nodes, CommandRequest{Command: "hostname"}, FleetOptions{
+		Concurrency: 2,
+		Progress: func(outcome NodeOutcome, done, total int) {
+			atomic.AddInt32(&progress, 1)
+			if total != len(nodes) {
+				t.Errorf("total expected %d, got %d", len(nodes), total)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if len(result.Succeeded) != 2 || result.Succeeded["ok2"].Stdout != "hello from ok2" {
+		t.Errorf("unexpected succeeded: %+v", result.Succeeded)
+	}
+	if _, ok := result.Failed["fail1"]; !ok || len(result.Failed) != 1 {
+		t.Errorf("unexpected failed: %+v", result.Failed)
+	}
+	if _, ok := result.Unreachable["offline1"]; !ok || len(result.Unreachable) != 1 {
+		t.Errorf("unexpected unreachable: %+v", result.Unreachable)
+	}
+	if progress != int32(len(nodes)) {
+		t.Errorf("expected %d progress calls, got %d", len(nodes), progress)
+	}
+	if requests != 3 {
+		t.Errorf("expected %d requests, got %d", 3, requests)
+	}
+}
+
+func TestCommandManyFailFast(t *testing.T) {
+	var requests int32
+	ts := newFleetServer(t, &requests)
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodes := []Report{
+		{ID: "fail1", SSHRemotePort: 10001},
+		{ID: "slow1", SSHRemotePort: 10002},
+		{ID: "ok1", SSHRemotePort: 10003},
+		{ID: "ok2", SSHRemotePort: 10004},
+	}
+	result, err := client.CommandMany(context.Background(), nodes, CommandRequest{Command: "hostname"}, FleetOptions{
+		Concurrency: 2,
+		FailFast:    true,
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil.")
+	}
+	if len(result.Failed) != 1 {
+		t.Errorf("unexpected failed: %+v", result.Failed)
+	}
+	if len(result.Succeeded)+len(result.Skipped) != 3 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestCommandManyNodeTimeout(t *testing.T) {
+	var requests int32
+	ts := newFleetServer(t, &requests)
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodes := []Report{{ID: "slow1", SSHRemotePort: 10001}}
+	result, err := client.CommandMany(context.Background(), nodes, CommandRequest{Command: "hostname"}, FleetOptions{
+		NodeTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if _, ok := result.Failed["slow1"]; !ok {
+		t.Errorf("expected slow1 to fail, got %+v", result)
+	}
+}
+
+func TestCommandManyWithSSHServerCredential(t *testing.T) {
+	var nodeLookups, serverLookups int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/servers/"):
+			atomic.AddInt32(&serverLookups, 1)
+			host := strings.TrimPrefix(r.URL.Path, "/servers/")
+			if host == "missing.example.com" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if _, err := w.Write([]byte(`{"host":"` + host + `","port":22,"user":"kaginawa","password":"pw-` + host + `"}`)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		case strings.HasSuffix(r.URL.Path, "/command"):
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("failed to parse form: %v", err)
+			}
+			if r.PostForm.Get("user") != "kaginawa" || !strings.HasPrefix(r.PostForm.Get("password"), "pw-") {
+				t.Errorf("unexpected form: %v", r.PostForm)
+			}
+			if _, err := w.Write([]byte(r.PostForm.Get("password"))); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		default:
+			atomic.AddInt32(&nodeLookups, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodes := []Report{
+		{ID: "n1", SSHServerHost: "ssh1.example.com", SSHRemotePort: 10001},
+		{ID: "n2", SSHServerHost: "ssh1.example.com", SSHRemotePort: 10002},
+		{ID: "n3", SSHServerHost: "ssh2.example.com", SSHRemotePort: 10003},
+		{ID: "n4", SSHServerHost: "missing.example.com", SSHRemotePort: 10004},
+		{ID: "n5", SSHServerHost: "missing.example.com", SSHRemotePort: 10005},
+	}
+	req := CommandRequest{Command: "hostname", Credential: SSHServerCredential{}}
+	result, err := client.CommandMany(context.Background(), nodes, req, FleetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeLookups != 0 {
+		t.Errorf("expected no node lookups, got %d", nodeLookups)
+	}
+	if serverLookups != 3 {
+		t.Errorf("expected %d server lookups, got %d", 3, serverLookups)
+	}
+	if len(result.Succeeded) != 3 || len(result.Failed) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if r := result.Succeeded["n3"]; r.Stdout != "pw-ssh2.example.com" || r.SSHServerHost != "ssh2.example.com" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
@@ -0,0 +1,154 @@
+package kaginawa
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthStatus is the grade of a report.
+type HealthStatus int
+
+// Health statuses, in order of severity.
+const (
+	HealthOK HealthStatus = iota
+	HealthWarn
+	HealthCritical
+)
+
+// String returns the name of the status.
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthOK:
+		return "ok"
+	case HealthWarn:
+		return "warn"
+	case HealthCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("HealthStatus(%d)", int(s))
+	}
+}
+
+// Health is the result of HealthCheck.
+type Health struct {
+	// Status is the most severe grade of all checks.
+	Status HealthStatus
+
+	// Reasons is the list of failed checks in human-readable form.
+	Reasons []string
+}
+
+// HealthCheck grades reports by thresholds. Zero value of each threshold disables the check.
+type HealthCheck struct {
+	// DiskWarnPercent is the disk usage to warn.
+	DiskWarnPercent float64
+
+	// DiskCriticalPercent is the disk usage to be critical.
+	DiskCriticalPercent float64
+
+	// StaleAfter is the age of the report (since ServerTime) to warn.
+	StaleAfter time.Duration
+
+	// DeadAfter is the age of the report (since ServerTime) to be critical.
+	DeadAfter time.Duration
+
+	// MaxClockSkew is the absolute clock skew to warn.
+	MaxClockSkew time.Duration
+
+	// MaxRTT is the round trip time to warn. Unmeasured reports are not checked.
+	MaxRTT time.Duration
+
+	// MinUploadMbps is the upload throughput to warn. Unmeasured reports are not checked.
+	MinUploadMbps float64
+
+	// MinDownloadMbps is the download throughput to warn. Unmeasured reports are not checked.
+	MinDownloadMbps float64
+
+	// RequireSSH warns if the node is not connected to any SSH server.
+	RequireSSH bool
+
+	// WarnOnErrors warns if the report has errors.
+	WarnOnErrors bool
+
+	// Now returns the current time. Nil means time.Now.
+	Now func() time.Time
+}
+
+// DefaultHealthCheck returns the recommended thresholds.
+func DefaultHealthCheck() HealthCheck {
+	return HealthCheck{
+		DiskWarnPercent:     80,
+		DiskCriticalPercent: 95,
+		StaleAfter:          15 * time.Minute,
+		DeadAfter:           time.Hour,
+		MaxClockSkew:        5 * time.Minute,
+		WarnOnErrors:        true,
+	}
+}
+
+// Grade checks the report and returns the health.
+func (h HealthCheck) Grade(r Report) Health {
+	var health Health
+	add := func(status HealthStatus, format string, args ...interface{}) {
+		if status > health.Status {
+			health.Status = status
+		}
+		health.Reasons = append(health.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	if r.DiskTotalBytes > 0 {
+		usage := r.DiskUsagePercent()
+		switch {
+		case h.DiskCriticalPercent > 0 && usage >= h.DiskCriticalPercent:
+			add(HealthCritical, "disk usage %.1f%% >= %.1f%%", usage, h.DiskCriticalPercent)
+		case h.DiskWarnPercent > 0 && usage >= h.DiskWarnPercent:
+			add(HealthWarn, "disk usage %.1f%% >= %.1f%%", usage, h.DiskWarnPercent)
+		}
+	}
+
+	if r.ServerTime > 0 && (h.StaleAfter > 0 || h.DeadAfter > 0) {
+		now := time.Now
+		if h.Now != nil {
+			now = h.Now
+		}
+		age := now().Sub(r.Timestamp())
+		switch {
+		case h.DeadAfter > 0 && age >= h.DeadAfter:
+			add(HealthCritical, "last report %v ago", age.Truncate(time.Second))
+		case h.StaleAfter > 0 && age >= h.StaleAfter:
+			add(HealthWarn, "last report %v ago", age.Truncate(time.Second))
+		}
+	}
+
+	if h.MaxClockSkew > 0 && r.DeviceTime > 0 && r.ServerTime > 0 {
+		skew := r.ClockSkew()
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > h.MaxClockSkew {
+			add(HealthWarn, "clock skew %v > %v", r.ClockSkew(), h.MaxClockSkew)
+		}
+	}
+
+	if h.MaxRTT > 0 && r.RTTMillis > 0 && r.RTT() > h.MaxRTT {
+		add(HealthWarn, "rtt %v > %v", r.RTT(), h.MaxRTT)
+	}
+	if h.MinUploadMbps > 0 && r.UploadKBPS > 0 && r.UploadMbps() < h.MinUploadMbps {
+		add(HealthWarn, "upload %.2f Mbps < %.2f Mbps", r.UploadMbps(), h.MinUploadMbps)
+	}
+	if h.MinDownloadMbps > 0 && r.DownloadKBPS > 0 && r.DownloadMbps() < h.MinDownloadMbps {
+		add(HealthWarn, "download %.2f Mbps < %.2f Mbps", r.DownloadMbps(), h.MinDownloadMbps)
+	}
+
+	if h.RequireSSH && !r.SSHConnected() {
+		add(HealthWarn, "not connected to ssh server")
+	}
+	if h.WarnOnErrors && (!r.Success || len(r.Errors) > 0) {
+		if len(r.Errors) > 0 {
+			add(HealthWarn, "%d report error(s): %s", len(r.Errors), r.Errors[0])
+		} else {
+			add(HealthWarn, "report failed")
+		}
+	}
+	return health
+}
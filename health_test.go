@@ -0,0 +1,90 @@
+package kaginawa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthStatusString(t *testing.T) {
+	tests := map[HealthStatus]string{
+		HealthOK:        "ok",
+		HealthWarn:      "warn",
+		HealthCritical:  "critical",
+		HealthStatus(9): "HealthStatus(9)",
+	}
+	for input, expected := range tests {
+		if actual := input.String(); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
+
+func TestHealthCheckGrade(t *testing.T) {
+	now := time.Unix(1587337308, 0)
+	check := DefaultHealthCheck()
+	check.Now = func() time.Time { return now }
+	check.MaxRTT = 100 * time.Millisecond
+	check.MinDownloadMbps = 10
+	check.RequireSSH = true
+	healthy := Report{
+		Success:        true,
+		DeviceTime:     now.Unix() - 1,
+		ServerTime:     now.Unix() - 60,
+		DiskTotalBytes: 100,
+		DiskUsedBytes:  50,
+		RTTMillis:      20,
+		DownloadKBPS:   50000,
+		SSHServerHost:  "example.com",
+		SSHRemotePort:  41383,
+	}
+	tests := []struct {
+		modify   func(r *Report)
+		expected HealthStatus
+		reason   string
+	}{
+		{modify: func(r *Report) {}, expected: HealthOK},
+		{modify: func(r *Report) { r.DiskUsedBytes = 85 }, expected: HealthWarn, reason: "disk usage 85.0%"},
+		{modify: func(r *Report) { r.DiskUsedBytes = 99 }, expected: HealthCritical, reason: "disk usage 99.0%"},
+		{modify: func(r *Report) { r.ServerTime, r.DeviceTime = now.Unix()-1200, now.Unix()-1200 }, expected: HealthWarn, reason: "last report 20m0s ago"},
+		{modify: func(r *Report) { r.ServerTime, r.DeviceTime = now.Unix()-7200, now.Unix()-7200 }, expected: HealthCritical, reason: "last report 2h0m0s ago"},
+		{modify: func(r *Report) { r.DeviceTime = r.ServerTime + 600 }, expected: HealthWarn, reason: "clock skew -10m0s"},
+		{modify: func(r *Report) { r.RTTMillis = 250 }, expected: HealthWarn, reason: "rtt 250ms"},
+		{modify: func(r *Report) { r.RTTMillis = 0 }, expected: HealthOK},
+		{modify: func(r *Report) { r.DownloadKBPS = 2000 }, expected: HealthWarn, reason: "download 2.00 Mbps"},
+		{modify: func(r *Report) { r.SSHRemotePort = 0 }, expected: HealthWarn, reason: "not connected"},
+		{
+			modify:   func(r *Report) { r.Success = false; r.Errors = []string{"disk: permission denied"} },
+			expected: HealthWarn,
+			reason:   "disk: permission denied",
+		},
+	}
+	for i, test := range tests {
+		r := healthy
+		test.modify(&r)
+		health := check.Grade(r)
+		if health.Status != test.expected {
+			t.Errorf("test %d: expected %v, got %v (%v)", i, test.expected, health.Status, health.Reasons)
+		}
+		if len(test.reason) == 0 {
+			if len(health.Reasons) > 0 {
+				t.Errorf("test %d: expected no reasons, got %v", i, health.Reasons)
+			}
+			continue
+		}
+		if len(health.Reasons) != 1 || !strings.Contains(health.Reasons[0], test.reason) {
+			t.Errorf("test %d: expected reason %q, got %v", i, test.reason, health.Reasons)
+		}
+	}
+}
+
+func TestHealthCheckMultipleReasons(t *testing.T) {
+	check := HealthCheck{DiskCriticalPercent: 90, RequireSSH: true}
+	health := check.Grade(Report{DiskTotalBytes: 100, DiskUsedBytes: 95})
+	if health.Status != HealthCritical {
+		t.Errorf("expected %v, got %v", HealthCritical, health.Status)
+	}
+	if len(health.Reasons) != 2 {
+		t.Errorf("expected %d reasons, got %v", 2, health.Reasons)
+	}
+}
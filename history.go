@@ -0,0 +1,156 @@
+package kaginawa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultHistoryWindow is the default time window of each request of HistoryIterator.
+const DefaultHistoryWindow = 24 * time.Hour
+
+// ReportIterator is a stream of reports, satisfied by *HistoryIterator.
+type ReportIterator interface {
+	Next() bool
+	Report() Report
+	Err() error
+}
+
+// HistoryIterator walks histories of a node in time-windowed chunks, in ascending order of ServerTime.
+// Each window is read and its response is released before the reports are yielded,
+// so memory usage is bounded by the window regardless of the range,
+// and the client can be used while iterating even with WithMaxInFlight.
+//
+//	it := client.Histories(ctx, id, begin, end, 0)
+//	defer it.Close()
+//	for it.Next() {
+//		report := it.Report()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type HistoryIterator struct {
+	client  *Client
+	ctx     context.Context
+	id      string
+	end     int64
+	window  int64
+	next    int64
+	buffer  []Report
+	current Report
+	err     error
+	done    bool
+}
+
+// Histories returns an iterator of histories of the node between beginTimestamp and endTimestamp (inclusive).
+// Zero endTimestamp means now. The window is the time range of each request; zero means DefaultHistoryWindow.
+func (c *Client) Histories(ctx context.Context, id string, beginTimestamp, endTimestamp int64, window time.Duration) *HistoryIterator {
+	if endTimestamp <= 0 {
+		endTimestamp = time.Now().Unix()
+	}
+	if window <= 0 {
+		window = DefaultHistoryWindow
+	}
+	it := &HistoryIterator{
+		client: c,
+		ctx:    ctx,
+		id:     id,
+		end:    endTimestamp,
+		window: int64(window / time.Second),
+		next:   beginTimestamp,
+	}
+	if it.window < 1 {
+		it.window = 1
+	}
+	if beginTimestamp <= 0 {
+		it.err = errors.New("most specify a begin timestamp")
+	}
+	return it
+}
+
+// Next advances the iterator to the next report.
+// It returns false when the iteration is finished or an error occurred.
+func (it *HistoryIterator) Next() bool {
+	for {
+		if it.err != nil || it.done {
+			return false
+		}
+		if len(it.buffer) > 0 {
+			it.current = it.buffer[0]
+			it.buffer = it.buffer[1:]
+			return true
+		}
+		if it.next > it.end {
+			it.done = true
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+}
+
+// Report returns the current report.
+func (it *HistoryIterator) Report() Report {
+	return it.current
+}
+
+// Err returns the error occurred during the iteration.
+func (it *HistoryIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and drops the buffered reports. It is safe to call Close multiple times.
+func (it *HistoryIterator) Close() error {
+	it.done = true
+	it.buffer = nil
+	return nil
+}
+
+// fetch reads the next window into the buffer, sorted by ServerTime.
+// The server returns each window newest first, and null for a window without histories.
+func (it *HistoryIterator) fetch() error {
+	begin := it.next
+	end := begin + it.window - 1
+	if end > it.end {
+		end = it.end
+	}
+	it.next = end + 1
+	values := NodeQuery{Projection: ProjectionMeasurement}.values()
+	values.Add("begin", strconv.FormatInt(begin, 10))
+	values.Add("end", strconv.FormatInt(end, 10))
+	path := fmt.Sprintf("%s%s/%s/histories?%s", it.client.endpoint, nodesResource, it.id, values.Encode())
+	resp, err := it.client.request(it.ctx, http.MethodGet, path, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer it.client.safeClose(resp.Body)
+	var reports []Report
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].ServerTime < reports[j].ServerTime })
+	it.buffer = reports
+	return nil
+}
+
+// ForEachHistory calls fn for each history of the node between beginTimestamp and endTimestamp (inclusive),
+// in ascending order of ServerTime.
+// The iteration stops when fn returns an error, and the error is returned.
+func (c *Client) ForEachHistory(ctx context.Context, id string, beginTimestamp, endTimestamp int64, fn func(Report) error) error {
+	it := c.Histories(ctx, id, beginTimestamp, endTimestamp, DefaultHistoryWindow)
+	defer it.Close()
+	for it.Next() {
+		if err := fn(it.Report()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
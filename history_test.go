@@ -0,0 +1,186 @@
+package kaginawa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newHistoriesServer(t *testing.T, requests *int32) *httptest.Server {
+	raw, err := ioutil.ReadFile("testdata/histories_multiple.json")
+	if err != nil {
+		t.Fatalf("failed to initialize testdata: %v", err)
+	}
+	var histories []Report
+	if err := json.Unmarshal(raw, &histories); err != nil {
+		t.Fatalf("failed to unmarshal testdata: %v", err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		begin, err := strconv.ParseInt(r.URL.Query().Get("begin"), 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			t.Errorf("invalid begin: %v", err)
+			return
+		}
+		end, err := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			t.Errorf("invalid end: %v", err)
+			return
+		}
+		matched := []Report{}
+		for _, h := range histories {
+			if h.ServerTime >= begin && h.ServerTime <= end {
+				matched = append(matched, h)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(matched); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestHistoryIterator(t *testing.T) {
+	tests := []struct {
+		window   time.Duration
+		requests int32
+	}{
+		{window: 10 * time.Minute, requests: 4},
+		{window: time.Hour, requests: 1},
+	}
+	for i, d := range tests {
+		var requests int32
+		ts := newHistoriesServer(t, &requests)
+		client, err := NewClient(ts.URL, testAPIKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		it := client.Histories(context.Background(), "b8:27:eb:36:83:e0", 1587336000, 1587338000, d.window)
+		var times []int64
+		for it.Next() {
+			times = append(times, it.Report().ServerTime)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("test %d: unexpexted error: %v", i, err)
+		}
+		it.Close()
+		ts.Close()
+		if len(times) != 2 {
+			t.Fatalf("test %d: expected %d record, got %d record(s)", i, 2, len(times))
+		}
+		if times[0] != 1587336708 || times[1] != 1587337308 {
+			t.Errorf("test %d: expected ascending server times, got %v", i, times)
+		}
+		if requests != d.requests {
+			t.Errorf("test %d: expected %d requests, got %d", i, d.requests, requests)
+		}
+	}
+}
+
+func TestHistoryIteratorWithNullWindow(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			if _, err := w.Write([]byte("null")); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+			return
+		}
+		if _, err := w.Write([]byte(`[{"id":"b8:27:eb:36:83:e0","server_time":1587337308}]`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	it := client.Histories(context.Background(), "b8:27:eb:36:83:e0", 1587336000, 1587337999, 30*time.Minute)
+	defer it.Close()
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected %d record, got %d record(s)", 1, count)
+	}
+	if requests != 2 {
+		t.Errorf("expected %d requests, got %d", 2, requests)
+	}
+}
+
+func TestHistoryIteratorWithMaxInFlight(t *testing.T) {
+	var requests int32
+	ts := newHistoriesServer(t, &requests)
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithMaxInFlight(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = client.ForEachHistory(ctx, "b8:27:eb:36:83:e0", 1587336000, 1587338000, func(r Report) error {
+		_, err := client.ListHistories(ctx, r.ID, r.ServerTime, r.ServerTime)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected %d requests, got %d", 3, requests)
+	}
+}
+
+func TestHistoryIteratorWithoutBegin(t *testing.T) {
+	client, err := NewClient("http://localhost:3000", testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	it := client.Histories(context.Background(), "b8:27:eb:36:83:e0", 0, 0, 0)
+	if it.Next() {
+		t.Error("expected no reports")
+	}
+	if it.Err() == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestForEachHistory(t *testing.T) {
+	var requests int32
+	ts := newHistoriesServer(t, &requests)
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	count := 0
+	err = client.ForEachHistory(context.Background(), "b8:27:eb:36:83:e0", 1587336000, 1587338000, func(r Report) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected %d record, got %d record(s)", 2, count)
+	}
+	stop := errors.New("stop")
+	err = client.ForEachHistory(context.Background(), "b8:27:eb:36:83:e0", 1587336000, 1587338000, func(r Report) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("expected %v, got %v", stop, err)
+	}
+}
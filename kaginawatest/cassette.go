@@ -0,0 +1,285 @@
+package kaginawatest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in the cassette.
+const Redacted = "REDACTED"
+
+// secretFields are form fields and JSON attributes to be redacted.
+var secretFields = map[string]bool{"key": true, "password": true}
+
+// Interaction is a recorded pair of request and response, stored as a line of the cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request part of the Interaction.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response part of the Interaction.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that writes each interaction to the cassette in JSON Lines.
+// The Authorization header is not recorded and SSH keys and passwords are redacted.
+type Recorder struct {
+	next http.RoundTripper
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// NewRecorder creates a Recorder that sends requests with next (nil means http.DefaultTransport)
+// and writes interactions to w.
+func NewRecorder(w io.Writer, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, w: w}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		_ = req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query().Encode(),
+			Body:   redactForm(string(reqBody)),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: recordedHeader(resp.Header),
+			Body:   string(redactJSON(resp.Header.Get("Content-Type"), respBody)),
+		},
+	}
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode interaction: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write interaction: %w", err)
+	}
+	return resp, nil
+}
+
+// Replayer is an http.RoundTripper that responds recorded interactions matched by method, path and query.
+// Interactions of the same request are replayed in the recorded order, and the last one is repeated.
+type Replayer struct {
+	// Strict makes RoundTrip fail on unmatched requests. Otherwise, 404 Not Found is returned.
+	Strict bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	unmatched    []string
+}
+
+// NewReplayer loads the cassette written by Recorder.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	replayer := &Replayer{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(line, &interaction); err != nil {
+			return nil, fmt.Errorf("failed to decode interaction: %w", err)
+		}
+		replayer.interactions = append(replayer.interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	replayer.used = make([]bool, len(replayer.interactions))
+	return replayer, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	query := req.URL.Query().Encode()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, interaction := range r.interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != req.URL.Path ||
+			interaction.Request.Query != query {
+			continue
+		}
+		last = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if last < 0 {
+		key := req.Method + " " + req.URL.Path
+		if len(query) > 0 {
+			key += "?" + query
+		}
+		r.unmatched = append(r.unmatched, key)
+		if r.Strict {
+			return nil, fmt.Errorf("kaginawatest: no recorded interaction for %s", key)
+		}
+		return newResponse(req, http.StatusNotFound, http.Header{}, `{"error":"no recorded interaction"}`), nil
+	}
+	r.used[last] = true
+	recorded := r.interactions[last].Response
+	return newResponse(req, recorded.Status, recorded.Header.Clone(), recorded.Body), nil
+}
+
+// Unused returns the number of recorded interactions that have not been replayed.
+func (r *Replayer) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, used := range r.used {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
+// Unmatched returns the requests that did not match any recorded interaction.
+func (r *Replayer) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unmatched := make([]string, len(r.unmatched))
+	copy(unmatched, r.unmatched)
+	return unmatched
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// recordedHeader keeps only the headers that affect the client behavior.
+func recordedHeader(header http.Header) http.Header {
+	recorded := http.Header{}
+	for _, key := range []string{"Content-Type", "Retry-After", "X-Request-Id"} {
+		if v := header.Get(key); len(v) > 0 {
+			recorded.Set(key, v)
+		}
+	}
+	return recorded
+}
+
+// redactForm replaces secret values of the form encoded body.
+func redactForm(body string) string {
+	if len(body) == 0 {
+		return body
+	}
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return body
+	}
+	for key := range form {
+		if secretFields[key] {
+			form.Set(key, Redacted)
+		}
+	}
+	return form.Encode()
+}
+
+// redactJSON replaces secret attributes of the JSON body.
+// Bodies of other content types or not consisting of a single JSON value are returned as-is.
+func redactJSON(contentType string, body []byte) []byte {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+		return body
+	}
+	if !json.Valid(body) {
+		return body
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return body
+	}
+	redacted, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if secretFields[key] {
+				if s, ok := value.(string); ok && len(s) > 0 {
+					t[key] = Redacted
+				}
+				continue
+			}
+			t[key] = redactValue(value)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,158 @@
+package kaginawatest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddReport(kaginawa.Report{ID: "a", CustomID: "rpi", SSHServerHost: "example.com", ServerTime: 1587337308})
+	s.AddSSHServer(kaginawa.SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Password: "test-pw"})
+	s.HandleCommand("a", "uptime", CommandResponse{Stdout: "up"})
+
+	var cassette bytes.Buffer
+	recording, err := s.NewClient(kaginawa.WithTransport(NewRecorder(&cassette, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := recording.FindNode(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := recording.ListNodesByCustomID(ctx, "rpi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := recording.FindSSHServerByHostname(ctx, "example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := recording.Command(ctx, "a", "uptime", "pi", "", "raspberry", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recorded := cassette.String()
+	if strings.Contains(recorded, "test-pw") || strings.Contains(recorded, "raspberry") || strings.Contains(recorded, s.APIKey) {
+		t.Errorf("secrets are not redacted: %s", recorded)
+	}
+	if lines := strings.Count(recorded, "\n"); lines != 4 {
+		t.Errorf("expected %d interactions, got %d", 4, lines)
+	}
+	s.Close()
+
+	replayer, err := NewReplayer(strings.NewReader(recorded))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replayer.Strict = true
+	replaying, err := kaginawa.NewClient("http://kaginawa.invalid", "any",
+		kaginawa.WithTransport(replayer), kaginawa.WithRetryPolicy(kaginawa.NoRetry()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err := replaying.FindNode(ctx, "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.ServerTime != 1587337308 {
+		t.Errorf("ServerTime expected %d, got %d", 1587337308, report.ServerTime)
+	}
+	nodes, err := replaying.ListNodesByCustomID(ctx, "rpi")
+	if err != nil || len(nodes) != 1 {
+		t.Errorf("expected %d node, got %v (%v)", 1, nodes, err)
+	}
+	server, err := replaying.FindSSHServerByHostname(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Password != Redacted {
+		t.Errorf("Password expected %s, got %s", Redacted, server.Password)
+	}
+	result, err := replaying.Command(ctx, "a", "uptime", "pi", "", "raspberry", 0)
+	if err != nil || result != "up" {
+		t.Errorf("expected %s, got %s (%v)", "up", result, err)
+	}
+	if replayer.Unused() != 0 {
+		t.Errorf("expected all interactions to be replayed, %d left", replayer.Unused())
+	}
+	if _, err := replaying.ListNodesByCustomID(ctx, "mac"); err == nil {
+		t.Error("expected error, got nil.")
+	}
+	if unmatched := replayer.Unmatched(); len(unmatched) != 1 {
+		t.Errorf("expected %d unmatched request, got %v", 1, unmatched)
+	}
+}
+
+func TestRecordAndReplayPlainText(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddReport(kaginawa.Report{ID: "a"})
+	s.HandleCommand("a", "uptime", CommandResponse{Stdout: "42\nLinux\n"})
+
+	var cassette bytes.Buffer
+	recording, err := s.NewClient(kaginawa.WithTransport(NewRecorder(&cassette, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := recording.Command(ctx, "a", "uptime", "pi", "", "raspberry", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Close()
+
+	replayer, err := NewReplayer(strings.NewReader(cassette.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replaying, err := kaginawa.NewClient("http://kaginawa.invalid", "any",
+		kaginawa.WithTransport(replayer), kaginawa.WithRetryPolicy(kaginawa.NoRetry()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := replaying.Command(ctx, "a", "uptime", "pi", "", "raspberry", 0)
+	if err != nil || result != "42\nLinux\n" {
+		t.Errorf("expected %q, got %q (%v)", "42\nLinux\n", result, err)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		expected    string
+	}{
+		{contentType: "application/json", body: `{"password":"pw"}`, expected: `{"password":"` + Redacted + `"}`},
+		{contentType: "application/json; charset=utf-8", body: `[{"key":"k"}]`, expected: `[{"key":"` + Redacted + `"}]`},
+		{contentType: "application/json", body: "42\nLinux\n", expected: "42\nLinux\n"},
+		{contentType: "text/plain", body: `{"password":"pw"}`, expected: `{"password":"pw"}`},
+		{contentType: "", body: "null", expected: "null"},
+	}
+	for i, d := range tests {
+		if actual := string(redactJSON(d.contentType, []byte(d.body))); actual != d.expected {
+			t.Errorf("test %d: expected %q, got %q", i, d.expected, actual)
+		}
+	}
+}
+
+func TestReplayerNonStrict(t *testing.T) {
+	replayer, err := NewReplayer(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://kaginawa.invalid/nodes", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := replayer.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
@@ -0,0 +1,2 @@
+// Package kaginawatest provides an in-memory fake Kaginawa Server for testing code that uses the SDK.
+package kaginawatest
@@ -0,0 +1,502 @@
+package kaginawatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// DefaultAPIKey is the API key accepted by the fake server.
+const DefaultAPIKey = "kaginawatest"
+
+// CommandResponse is the scripted response of the command endpoint.
+// By default it is written in plain text like the real server, with Stdout and Stderr merged.
+type CommandResponse struct {
+	// Stdout is the standard output of the command.
+	Stdout string
+
+	// Stderr is the standard error of the command.
+	Stderr string
+
+	// ExitCode is the exit status of the command. It is only sent with JSON.
+	ExitCode int
+
+	// JSON enables the structured JSON response carrying stdout, stderr and exit_code separately.
+	JSON bool
+
+	// Status is the HTTP status code. Zero means 200 OK.
+	Status int
+
+	// Error is the error message returned with a non-200 status.
+	Error string
+}
+
+// CommandHandler generates the response of the command endpoint.
+// The form contains command, user, key, password and timeout values.
+type CommandHandler func(nodeID string, form url.Values) CommandResponse
+
+// Request is a request received by the fake server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Form   url.Values
+	Header http.Header
+	Time   time.Time
+}
+
+// Server is a stateful fake Kaginawa Server.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+
+	// APIKey is the accepted API key.
+	APIKey string
+
+	ts             *httptest.Server
+	mu             sync.Mutex
+	nodes          map[string]kaginawa.Report
+	histories      map[string][]kaginawa.Report
+	servers        map[string]kaginawa.SSHServer
+	commands       map[string]CommandResponse
+	commandHandler CommandHandler
+	requests       []Request
+	latency        time.Duration
+	failures       []int
+	authFailure    bool
+}
+
+// NewServer starts a fake server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		APIKey:    DefaultAPIKey,
+		nodes:     map[string]kaginawa.Report{},
+		histories: map[string][]kaginawa.Report{},
+		servers:   map[string]kaginawa.SSHServer{},
+		commands:  map[string]CommandResponse{},
+	}
+	s.ts = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.ts.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// NewClient creates a client connected to the server.
+func (s *Server) NewClient(opts ...kaginawa.Option) (*kaginawa.Client, error) {
+	return kaginawa.NewClient(s.URL, s.APIKey, opts...)
+}
+
+// AddReport seeds a report. It becomes the latest report of the node and is appended to the histories.
+// Zero ServerTime is replaced by the current time.
+func (s *Server) AddReport(report kaginawa.Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if report.ServerTime == 0 {
+		report.ServerTime = time.Now().Unix()
+	}
+	if latest, ok := s.nodes[report.ID]; !ok || latest.ServerTime <= report.ServerTime {
+		s.nodes[report.ID] = report
+	}
+	s.histories[report.ID] = append(s.histories[report.ID], report)
+}
+
+// AddSSHServer seeds an SSH server entry.
+func (s *Server) AddSSHServer(server kaginawa.SSHServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers[server.Host] = server
+}
+
+// SSHServers returns the current SSH server entries ordered by host.
+func (s *Server) SSHServers() []kaginawa.SSHServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedServers()
+}
+
+// Reports returns the latest reports ordered by id.
+func (s *Server) Reports() []kaginawa.Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedNodes()
+}
+
+// HandleCommand scripts the response of the command on the node.
+func (s *Server) HandleCommand(nodeID, command string, resp CommandResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands[nodeID+"\x00"+command] = resp
+}
+
+// SetCommandHandler sets the fallback handler of commands that are not scripted by HandleCommand.
+func (s *Server) SetCommandHandler(handler CommandHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commandHandler = handler
+}
+
+// SetLatency delays every response.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// FailNext makes the next n requests respond with the status code such as 503.
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// SetAuthFailure makes every request respond 401 regardless of the API key.
+func (s *Server) SetAuthFailure(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authFailure = fail
+}
+
+// Requests returns the received requests in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+// ClearRequests forgets the received requests.
+func (s *Server) ClearRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Form:   r.PostForm,
+		Header: r.Header.Clone(),
+		Time:   time.Now(),
+	})
+	latency := s.latency
+	authFailure := s.authFailure
+	failure := 0
+	if len(s.failures) > 0 {
+		failure = s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if authFailure || r.Header.Get("Authorization") != "token "+s.APIKey {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+	if failure > 0 {
+		writeError(w, failure, http.StatusText(failure))
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(segments) == 1 && segments[0] == "nodes" && r.Method == http.MethodGet:
+		s.listNodes(w, r)
+	case len(segments) == 2 && segments[0] == "nodes" && r.Method == http.MethodGet:
+		s.findNode(w, segments[1])
+	case len(segments) == 2 && segments[0] == "nodes" && r.Method == http.MethodDelete:
+		s.deleteNode(w, segments[1])
+	case len(segments) == 3 && segments[0] == "nodes" && segments[2] == "histories" && r.Method == http.MethodGet:
+		s.listHistories(w, r, segments[1])
+	case len(segments) == 3 && segments[0] == "nodes" && segments[2] == "histories" && r.Method == http.MethodDelete:
+		s.deleteHistories(w, r, segments[1])
+	case len(segments) == 3 && segments[0] == "nodes" && segments[2] == "command" && r.Method == http.MethodPost:
+		s.command(w, r, segments[1])
+	case len(segments) == 1 && segments[0] == "servers" && r.Method == http.MethodGet:
+		s.mu.Lock()
+		servers := s.sortedServers()
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, servers)
+	case len(segments) == 1 && segments[0] == "servers" && r.Method == http.MethodPost:
+		s.saveServer(w, r, "", http.StatusCreated)
+	case len(segments) == 2 && segments[0] == "servers" && r.Method == http.MethodGet:
+		s.findServer(w, segments[1])
+	case len(segments) == 2 && segments[0] == "servers" && r.Method == http.MethodPut:
+		s.saveServer(w, r, segments[1], http.StatusOK)
+	case len(segments) == 2 && segments[0] == "servers" && r.Method == http.MethodDelete:
+		s.deleteServer(w, segments[1])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listNodes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	nodes := s.sortedNodes()
+	s.mu.Unlock()
+	matched := []kaginawa.Report{}
+	for _, n := range nodes {
+		if !match(query, "custom-id", n.CustomID) || !match(query, "hostname", n.Hostname) ||
+			!match(query, "runtime", n.Runtime) || !match(query, "agent-version", n.AgentVersion) ||
+			!match(query, "ssh-server-host", n.SSHServerHost) ||
+			!match(query, "success", strconv.FormatBool(n.Success)) {
+			continue
+		}
+		if minutes, err := strconv.Atoi(query.Get("minutes")); err == nil && minutes > 0 {
+			if time.Since(n.Timestamp()) > time.Duration(minutes)*time.Minute {
+				continue
+			}
+		}
+		matched = append(matched, n)
+	}
+	writeJSON(w, http.StatusOK, paginate(matched, query))
+}
+
+func (s *Server) findNode(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	node, ok := s.nodes[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "node not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, node)
+}
+
+func (s *Server) deleteNode(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	_, ok := s.nodes[id]
+	delete(s.nodes, id)
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "node not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listHistories(w http.ResponseWriter, r *http.Request, id string) {
+	begin, end, err := parseRange(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	histories := s.histories[id]
+	s.mu.Unlock()
+	matched := []kaginawa.Report{}
+	for _, h := range histories {
+		if h.ServerTime >= begin && (end == 0 || h.ServerTime <= end) {
+			matched = append(matched, h)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].ServerTime > matched[j].ServerTime })
+	writeJSON(w, http.StatusOK, matched)
+}
+
+func (s *Server) deleteHistories(w http.ResponseWriter, r *http.Request, id string) {
+	begin, end, err := parseRange(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dryRun := r.URL.Query().Get("dry-run") == "true"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []kaginawa.Report
+	count := 0
+	for _, h := range s.histories[id] {
+		if h.ServerTime >= begin && (end == 0 || h.ServerTime <= end) {
+			count++
+			continue
+		}
+		kept = append(kept, h)
+	}
+	if !dryRun {
+		s.histories[id] = kept
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"count": count})
+}
+
+func (s *Server) command(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	_, ok := s.nodes[id]
+	resp, scripted := s.commands[id+"\x00"+r.PostForm.Get("command")]
+	handler := s.commandHandler
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "node not found")
+		return
+	}
+	if !scripted {
+		if handler == nil {
+			writeError(w, http.StatusInternalServerError, "no scripted response: "+r.PostForm.Get("command"))
+			return
+		}
+		resp = handler(id, r.PostForm)
+	}
+	if resp.Status != 0 && resp.Status != http.StatusOK {
+		writeError(w, resp.Status, resp.Error)
+		return
+	}
+	if !resp.JSON {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(resp.Stdout + resp.Stderr))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stdout":    resp.Stdout,
+		"stderr":    resp.Stderr,
+		"exit_code": resp.ExitCode,
+	})
+}
+
+func (s *Server) findServer(w http.ResponseWriter, host string) {
+	s.mu.Lock()
+	server, ok := s.servers[host]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, server)
+}
+
+func (s *Server) saveServer(w http.ResponseWriter, r *http.Request, host string, status int) {
+	port, err := strconv.Atoi(r.PostForm.Get("port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid port")
+		return
+	}
+	server := kaginawa.SSHServer{
+		Host:     r.PostForm.Get("host"),
+		Port:     port,
+		User:     r.PostForm.Get("user"),
+		Key:      r.PostForm.Get("key"),
+		Password: r.PostForm.Get("password"),
+	}
+	if err := server.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.servers[server.Host]
+	switch {
+	case len(host) == 0 && exists:
+		writeError(w, http.StatusConflict, "server already exists")
+		return
+	case len(host) > 0 && host != server.Host:
+		writeError(w, http.StatusBadRequest, "host mismatch")
+		return
+	case len(host) > 0 && !exists:
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	s.servers[server.Host] = server
+	w.WriteHeader(status)
+}
+
+func (s *Server) deleteServer(w http.ResponseWriter, host string) {
+	s.mu.Lock()
+	_, ok := s.servers[host]
+	delete(s.servers, host)
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) sortedNodes() []kaginawa.Report {
+	nodes := make([]kaginawa.Report, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+func (s *Server) sortedServers() []kaginawa.SSHServer {
+	servers := make([]kaginawa.SSHServer, 0, len(s.servers))
+	for _, sv := range s.servers {
+		servers = append(servers, sv)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Host < servers[j].Host })
+	return servers
+}
+
+func match(query url.Values, key, value string) bool {
+	expected, ok := query[key]
+	return !ok || expected[0] == value
+}
+
+func paginate(reports []kaginawa.Report, query url.Values) []kaginawa.Report {
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset > 0 {
+		if offset >= len(reports) {
+			return []kaginawa.Report{}
+		}
+		reports = reports[offset:]
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit < len(reports) {
+		reports = reports[:limit]
+	}
+	return reports
+}
+
+func parseRange(query url.Values) (int64, int64, error) {
+	var begin, end int64
+	var err error
+	if v := query.Get("begin"); len(v) > 0 {
+		if begin, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid begin: %s", v)
+		}
+	}
+	if v := query.Get("end"); len(v) > 0 {
+		if end, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid end: %s", v)
+		}
+	}
+	return begin, end, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
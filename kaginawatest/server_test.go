@@ -0,0 +1,151 @@
+package kaginawatest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+func newTestServer(t *testing.T) (*Server, *kaginawa.Client) {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	client, err := s.NewClient(kaginawa.WithRetryPolicy(kaginawa.NoRetry()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s, client
+}
+
+func TestNodes(t *testing.T) {
+	s, client := newTestServer(t)
+	now := time.Now().Unix()
+	s.AddReport(kaginawa.Report{ID: "a", CustomID: "rpi", Success: true, ServerTime: now - 3600})
+	s.AddReport(kaginawa.Report{ID: "a", CustomID: "rpi", Success: true, ServerTime: now})
+	s.AddReport(kaginawa.Report{ID: "b", CustomID: "rpi", Success: false, ServerTime: now - 7200})
+	s.AddReport(kaginawa.Report{ID: "c", CustomID: "mac", Success: true, ServerTime: now})
+	ctx := context.Background()
+
+	alive, err := client.ListAliveNodes(ctx, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alive) != 2 {
+		t.Errorf("expected %d alive nodes, got %d", 2, len(alive))
+	}
+	rpi, err := client.ListNodesByCustomID(ctx, "rpi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rpi) != 2 {
+		t.Errorf("expected %d nodes, got %d", 2, len(rpi))
+	}
+	failed := false
+	if nodes, err := client.ListNodes(ctx, kaginawa.NodeQuery{Success: &failed}); err != nil || len(nodes) != 1 {
+		t.Errorf("expected %d failed node, got %v (%v)", 1, nodes, err)
+	}
+	histories, err := client.ListHistories(ctx, "a", now-7200, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(histories) != 2 || histories[0].ServerTime != now {
+		t.Errorf("unexpected histories: %v", histories)
+	}
+	count, err := client.DeleteHistories(ctx, "a", 0, now-1, false)
+	if err != nil || count != 1 {
+		t.Errorf("expected %d deleted history, got %d (%v)", 1, count, err)
+	}
+	if err := client.DeleteNode(ctx, "b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.FindNode(ctx, "b"); !errors.Is(err, kaginawa.ErrNotFound) {
+		t.Errorf("expected %v, got %v", kaginawa.ErrNotFound, err)
+	}
+}
+
+func TestSSHServers(t *testing.T) {
+	s, client := newTestServer(t)
+	ctx := context.Background()
+	server := kaginawa.SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Password: "test-pw"}
+	if err := client.CreateSSHServer(ctx, server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Port = 2222
+	if err := client.UpdateSSHServer(ctx, server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found, err := client.FindSSHServerByHostname(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found.Port != 2222 {
+		t.Errorf("Port expected %d, got %d", 2222, found.Port)
+	}
+	if err := client.DeleteSSHServer(ctx, "example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.SSHServers()) != 0 {
+		t.Errorf("expected no servers, got %v", s.SSHServers())
+	}
+}
+
+func TestCommand(t *testing.T) {
+	s, client := newTestServer(t)
+	s.AddReport(kaginawa.Report{ID: "a", SSHRemotePort: 10000})
+	s.HandleCommand("a", "uptime", CommandResponse{Stdout: "up 3 days", ExitCode: 0})
+	s.HandleCommand("a", "whoami", CommandResponse{Status: http.StatusInternalServerError, Error: "ssh: unable to authenticate"})
+	s.SetCommandHandler(func(nodeID string, form url.Values) CommandResponse {
+		return CommandResponse{Stderr: "not found: " + form.Get("command"), ExitCode: 127, JSON: true}
+	})
+	ctx := context.Background()
+	result, err := client.RunCommand(ctx, "a", kaginawa.CommandRequest{Command: "uptime", User: "pi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stdout != "up 3 days" || result.ExitCodeKnown {
+		t.Errorf("unexpected plain text result: %+v", result)
+	}
+	result, err = client.RunCommand(ctx, "a", kaginawa.CommandRequest{Command: "foo"})
+	if err != nil || result.ExitCode != 127 || !result.ExitCodeKnown || result.Stderr != "not found: foo" {
+		t.Errorf("unexpected JSON result: %+v (%v)", result, err)
+	}
+	if _, err := client.RunCommand(ctx, "a", kaginawa.CommandRequest{Command: "whoami"}); !errors.Is(err, kaginawa.ErrCommandAuthFailed) {
+		t.Errorf("expected %v, got %v", kaginawa.ErrCommandAuthFailed, err)
+	}
+	requests := s.Requests()
+	if len(requests) != 3 || requests[0].Form.Get("user") != "pi" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s, client := newTestServer(t)
+	s.AddReport(kaginawa.Report{ID: "a"})
+	ctx := context.Background()
+
+	s.FailNext(1, http.StatusServiceUnavailable)
+	var apiErr *kaginawa.APIError
+	if _, err := client.FindNode(ctx, "a"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %v", err)
+	}
+	if _, err := client.FindNode(ctx, "a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	s.SetAuthFailure(true)
+	if _, err := client.FindNode(ctx, "a"); !errors.Is(err, kaginawa.ErrUnauthorized) {
+		t.Errorf("expected %v, got %v", kaginawa.ErrUnauthorized, err)
+	}
+	s.SetAuthFailure(false)
+
+	s.SetLatency(time.Second)
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.FindNode(timeout, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,118 @@
+package kaginawa
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultThrottleDelay is the pause applied to the limiter when the server responds 429 without Retry-After.
+const defaultThrottleDelay = time.Second
+
+// WithRateLimit limits the request rate of the Client using a token bucket.
+// Up to burst requests can be sent at once, then requestsPerSecond requests are allowed per second.
+// The limit is shared across all methods of the Client.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = newRateLimiter(requestsPerSecond, burst)
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent requests of the Client.
+// A request occupies the slot until its response body is closed.
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.inFlight = make(chan struct{}, n)
+		} else {
+			c.inFlight = nil
+		}
+	}
+}
+
+// rateLimiter is a token bucket rate limiter that can be paused by server-side throttling.
+// Zero rate means unlimited, but the pause is still honored.
+type rateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		d := l.reserve(time.Now())
+		if d <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token and returns zero, or returns the duration to wait for the next token.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// pause stops issuing tokens for the duration and drains the bucket.
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if until := now.Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.tokens = 0
+	l.last = now
+}
+
+// acquire occupies an in-flight slot and returns the release function.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	if c.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-c.inFlight }) }, nil
+}
@@ -0,0 +1,107 @@
+package kaginawa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(10, 2)
+	now := l.last
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("1st reserve expected 0, got %v", d)
+	}
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("2nd reserve expected 0, got %v", d)
+	}
+	if d := l.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("3rd reserve expected %v, got %v", 100*time.Millisecond, d)
+	}
+	if d := l.reserve(now.Add(100 * time.Millisecond)); d != 0 {
+		t.Errorf("reserve after refill expected 0, got %v", d)
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := newRateLimiter(0.001, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	l := newRateLimiter(0, 1)
+	l.pause(time.Hour)
+	if d := l.reserve(time.Now()); d <= 0 {
+		t.Errorf("expected positive wait, got %v", d)
+	}
+}
+
+func TestWithMaxInFlight(t *testing.T) {
+	var current, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(`{"id":"f0:18:98:eb:c7:27"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithMaxInFlight(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.FindNode(context.Background(), "f0:18:98:eb:c7:27"); err != nil {
+				t.Errorf("unexpexted error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("expected at most %d concurrent requests, got %d", 2, peak)
+	}
+}
+
+func TestTooManyRequestsPausesLimiter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.FindNode(context.Background(), "f0:18:98:eb:c7:27"); err == nil {
+		t.Fatal("expected error, got nil.")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.FindNode(ctx, "f0:18:98:eb:c7:27"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,60 @@
+package kaginawa
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Option configures optional behavior of the Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the underlying HTTP client with a copy of httpClient.
+// WithTimeout and WithTransport are applied to the copy regardless of the order of options.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			copied := *httpClient
+			c.client = &copied
+		}
+	}
+}
+
+// WithTimeout specifies the time limit of each HTTP request, including reading the response body.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = &timeout
+	}
+}
+
+// WithTransport specifies the HTTP transport such as a customized *http.Transport with proxy or TLS settings.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithUserAgent specifies the User-Agent header of each request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeader adds an extra header to each request.
+// The Authorization header cannot be overridden.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithBaseContext specifies the parent context of all requests.
+// Canceling the base context cancels all in-flight and future requests of the Client.
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *Client) {
+		if ctx != nil {
+			c.baseCtx = ctx
+		}
+	}
+}
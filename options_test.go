@@ -0,0 +1,117 @@
+package kaginawa
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithUserAgentAndHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); ua != "test-agent/1.0" {
+			t.Errorf("User-Agent expected %s, got %s", "test-agent/1.0", ua)
+		}
+		if v := r.Header.Get("X-Tenant"); v != "acme" {
+			t.Errorf("X-Tenant expected %s, got %s", "acme", v)
+		}
+		if v := r.Header.Get("Authorization"); v != "token "+testAPIKey {
+			t.Errorf("Authorization expected %s, got %s", "token "+testAPIKey, v)
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("[]")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey,
+		WithUserAgent("test-agent/1.0"),
+		WithHeader("X-Tenant", "acme"),
+		WithHeader("Authorization", "token overridden"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ListAliveNodes(context.Background(), 0); err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	called := false
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":"f0:18:98:eb:c7:27"}`)),
+			Request:    req,
+		}, nil
+	})
+	base := &http.Client{}
+	client, err := NewClient("http://localhost:3000", testAPIKey, WithTransport(transport), WithHTTPClient(base))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err := client.FindNode(context.Background(), "f0:18:98:eb:c7:27")
+	if err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if !called {
+		t.Error("expected custom transport to be called")
+	}
+	if report.ID != "f0:18:98:eb:c7:27" {
+		t.Errorf("expected ID %s, got %s", "f0:18:98:eb:c7:27", report.ID)
+	}
+	if base.Transport != nil {
+		t.Error("expected the original http client to be untouched")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	tests := [][]Option{
+		{WithTimeout(10 * time.Millisecond), WithRetryPolicy(NoRetry())},
+		{WithTimeout(10 * time.Millisecond), WithHTTPClient(&http.Client{}), WithRetryPolicy(NoRetry())},
+	}
+	for i, opts := range tests {
+		client, err := NewClient(ts.URL, testAPIKey, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.FindNode(context.Background(), "f0:18:98:eb:c7:27"); err == nil {
+			t.Errorf("test %d: expected error, got nil.", i)
+		}
+	}
+}
+
+func TestWithBaseContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	base, cancel := context.WithCancel(context.Background())
+	cancel()
+	client, err := NewClient(ts.URL, testAPIKey, WithBaseContext(base))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.FindNode(context.Background(), "f0:18:98:eb:c7:27")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,89 @@
+package kaginawa
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Projection specifies the set of report attributes returned by the server.
+type Projection string
+
+const (
+	// ProjectionFull returns all attributes of the reports.
+	ProjectionFull Projection = ""
+
+	// ProjectionID returns only the identification attributes of the reports.
+	ProjectionID Projection = "id"
+
+	// ProjectionMeasurement returns the identification and measurement attributes of the reports.
+	ProjectionMeasurement Projection = "measurement"
+)
+
+// NodeQuery defines filters and projection of ListNodes.
+// Zero values are ignored.
+type NodeQuery struct {
+	// Projection is the set of attributes to be returned.
+	Projection Projection
+
+	// CustomID filters nodes by the user-specified device identification string.
+	CustomID string
+
+	// Hostname filters nodes by the hostname of the device.
+	Hostname string
+
+	// Runtime filters nodes by the runtime environment such as "linux arm".
+	Runtime string
+
+	// AgentVersion filters nodes by the Kaginawa software version.
+	AgentVersion string
+
+	// SSHServerHost filters nodes by the hostname of the connected SSH server.
+	SSHServerHost string
+
+	// Success filters nodes by the success flag of the latest report.
+	Success *bool
+
+	// Minutes filters nodes that reported within the minutes (alive nodes).
+	Minutes int
+
+	// Limit is the maximum number of nodes to be returned.
+	Limit int
+
+	// Offset is the number of nodes to be skipped.
+	Offset int
+}
+
+func (q NodeQuery) values() url.Values {
+	values := url.Values{}
+	if len(q.Projection) > 0 {
+		values.Add("projection", string(q.Projection))
+	}
+	if len(q.CustomID) > 0 {
+		values.Add("custom-id", q.CustomID)
+	}
+	if len(q.Hostname) > 0 {
+		values.Add("hostname", q.Hostname)
+	}
+	if len(q.Runtime) > 0 {
+		values.Add("runtime", q.Runtime)
+	}
+	if len(q.AgentVersion) > 0 {
+		values.Add("agent-version", q.AgentVersion)
+	}
+	if len(q.SSHServerHost) > 0 {
+		values.Add("ssh-server-host", q.SSHServerHost)
+	}
+	if q.Success != nil {
+		values.Add("success", strconv.FormatBool(*q.Success))
+	}
+	if q.Minutes > 0 {
+		values.Add("minutes", strconv.Itoa(q.Minutes))
+	}
+	if q.Limit > 0 {
+		values.Add("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		values.Add("offset", strconv.Itoa(q.Offset))
+	}
+	return values
+}
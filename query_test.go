@@ -0,0 +1,38 @@
+package kaginawa
+
+import "testing"
+
+func TestNodeQueryValues(t *testing.T) {
+	success := false
+	tests := []struct {
+		input    NodeQuery
+		expected string
+	}{
+		{
+			input:    NodeQuery{},
+			expected: "",
+		},
+		{
+			input:    NodeQuery{Projection: ProjectionID, Minutes: 5},
+			expected: "minutes=5&projection=id",
+		},
+		{
+			input:    NodeQuery{CustomID: "test-rpi", Hostname: "test-rpi.local", Runtime: "linux arm"},
+			expected: "custom-id=test-rpi&hostname=test-rpi.local&runtime=linux+arm",
+		},
+		{
+			input:    NodeQuery{AgentVersion: "v0.0.7", SSHServerHost: "example.com", Success: &success},
+			expected: "agent-version=v0.0.7&ssh-server-host=example.com&success=false",
+		},
+		{
+			input:    NodeQuery{Projection: ProjectionMeasurement, Limit: 100, Offset: 200},
+			expected: "limit=100&offset=200&projection=measurement",
+		},
+	}
+	for i, d := range tests {
+		actual := d.input.values().Encode()
+		if d.expected != actual {
+			t.Errorf("test %d: values() expected %s, got %s", i, d.expected, actual)
+		}
+	}
+}
@@ -137,3 +137,69 @@ func (r Report) Timestamp() time.Time {
 func (r Report) BootTimestamp() time.Time {
 	return time.Unix(r.BootTime, 0)
 }
+
+// DeviceTimestamp returns Time object from DeviceTime.
+func (r Report) DeviceTimestamp() time.Time {
+	return time.Unix(r.DeviceTime, 0)
+}
+
+// SSHConnectTimestamp returns Time object from SSHConnectTime.
+func (r Report) SSHConnectTimestamp() time.Time {
+	return time.Unix(r.SSHConnectTime, 0)
+}
+
+// ClockSkew returns the difference between ServerTime and DeviceTime.
+// Positive value means the device clock is behind the server clock (or the report took time to deliver).
+func (r Report) ClockSkew() time.Duration {
+	return time.Duration(r.ServerTime-r.DeviceTime) * time.Second
+}
+
+// DiskUsagePercent returns the used disk space in percent, or 0 if the total disk space is unknown.
+func (r Report) DiskUsagePercent() float64 {
+	if r.DiskTotalBytes <= 0 {
+		return 0
+	}
+	return float64(r.DiskUsedBytes) / float64(r.DiskTotalBytes) * 100
+}
+
+// DiskFreeBytes returns the free disk space in bytes.
+func (r Report) DiskFreeBytes() int64 {
+	return r.DiskTotalBytes - r.DiskUsedBytes
+}
+
+// Uptime returns the running time of the kaginawa process at the report time, or 0 if unknown.
+func (r Report) Uptime() time.Duration {
+	if r.BootTime <= 0 || r.DeviceTime < r.BootTime {
+		return 0
+	}
+	return time.Duration(r.DeviceTime-r.BootTime) * time.Second
+}
+
+// SSHSessionAge returns the elapsed time since connected to the SSH server at the report time,
+// or 0 if not connected.
+func (r Report) SSHSessionAge() time.Duration {
+	if r.SSHConnectTime <= 0 || r.DeviceTime < r.SSHConnectTime {
+		return 0
+	}
+	return time.Duration(r.DeviceTime-r.SSHConnectTime) * time.Second
+}
+
+// SSHConnected reports whether the node is connected to the SSH server.
+func (r Report) SSHConnected() bool {
+	return len(r.SSHServerHost) > 0 && r.SSHRemotePort > 0
+}
+
+// RTT returns the measured round trip time.
+func (r Report) RTT() time.Duration {
+	return time.Duration(r.RTTMillis) * time.Millisecond
+}
+
+// UploadMbps returns the measured upload throughput in Mbps.
+func (r Report) UploadMbps() float64 {
+	return float64(r.UploadKBPS) / 1000
+}
+
+// DownloadMbps returns the measured download throughput in Mbps.
+func (r Report) DownloadMbps() float64 {
+	return float64(r.DownloadKBPS) / 1000
+}
@@ -67,3 +67,100 @@ func TestUnmarshalReport(t *testing.T) {
 		t.Errorf("unexpected payload cmd: %s", report.PayloadCmd)
 	}
 }
+
+func TestDeviceTimestamp(t *testing.T) {
+	r := Report{DeviceTime: 1600000000}
+	expected := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC).Local()
+	if actual := r.DeviceTimestamp(); expected != actual {
+		t.Errorf("DeviceTimestamp() expected %v, got %v", expected, actual)
+	}
+}
+
+func TestSSHConnectTimestamp(t *testing.T) {
+	r := Report{SSHConnectTime: 1600000000}
+	expected := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC).Local()
+	if actual := r.SSHConnectTimestamp(); expected != actual {
+		t.Errorf("SSHConnectTimestamp() expected %v, got %v", expected, actual)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	tests := []struct {
+		input    Report
+		expected time.Duration
+	}{
+		{
+			input:    Report{DeviceTime: 1587337307, ServerTime: 1587337308},
+			expected: time.Second,
+		},
+		{
+			input:    Report{DeviceTime: 1587337367, ServerTime: 1587337308},
+			expected: -59 * time.Second,
+		},
+	}
+	for i, d := range tests {
+		actual := d.input.ClockSkew()
+		if d.expected != actual {
+			t.Errorf("test %d: ClockSkew() expected %v, got %v", i, d.expected, actual)
+		}
+	}
+}
+
+func TestDiskUsagePercent(t *testing.T) {
+	tests := []struct {
+		input    Report
+		expected float64
+	}{
+		{input: Report{DiskTotalBytes: 1000, DiskUsedBytes: 250}, expected: 25},
+		{input: Report{DiskTotalBytes: 0, DiskUsedBytes: 250}, expected: 0},
+	}
+	for i, test := range tests {
+		if actual := test.input.DiskUsagePercent(); actual != test.expected {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, actual)
+		}
+	}
+	if actual := (Report{DiskTotalBytes: 1000, DiskUsedBytes: 250}).DiskFreeBytes(); actual != 750 {
+		t.Errorf("DiskFreeBytes() expected %d, got %d", 750, actual)
+	}
+}
+
+func TestUptimeAndSSHSessionAge(t *testing.T) {
+	tests := []struct {
+		input  Report
+		uptime time.Duration
+		ssh    time.Duration
+	}{
+		{
+			input:  Report{BootTime: 1587330000, SSHConnectTime: 1587337000, DeviceTime: 1587337300},
+			uptime: 7300 * time.Second,
+			ssh:    5 * time.Minute,
+		},
+		{
+			input: Report{DeviceTime: 1587337300},
+		},
+		{
+			input: Report{BootTime: 1587337400, SSHConnectTime: 1587337400, DeviceTime: 1587337300},
+		},
+	}
+	for i, test := range tests {
+		if actual := test.input.Uptime(); actual != test.uptime {
+			t.Errorf("test %d: Uptime() expected %v, got %v", i, test.uptime, actual)
+		}
+		if actual := test.input.SSHSessionAge(); actual != test.ssh {
+			t.Errorf("test %d: SSHSessionAge() expected %v, got %v", i, test.ssh, actual)
+		}
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	r := Report{RTTMillis: 42, UploadKBPS: 1500, DownloadKBPS: 25000}
+	if actual := r.RTT(); actual != 42*time.Millisecond {
+		t.Errorf("RTT() expected %v, got %v", 42*time.Millisecond, actual)
+	}
+	if actual := r.UploadMbps(); actual != 1.5 {
+		t.Errorf("UploadMbps() expected %v, got %v", 1.5, actual)
+	}
+	if actual := r.DownloadMbps(); actual != 25 {
+		t.Errorf("DownloadMbps() expected %v, got %v", 25, actual)
+	}
+}
@@ -0,0 +1,132 @@
+package kaginawa
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy defines how failed requests are retried.
+// GET requests are retried by default; Command is retried only if RetryCommand is true.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	// Zero or one disables retrying.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. The delay doubles on each retry.
+	BaseDelay time.Duration
+
+	// MaxDelay is the upper limit of the delay, including the one requested by Retry-After.
+	// Zero means no limit.
+	MaxDelay time.Duration
+
+	// Jitter is the randomization factor (0.0 to 1.0) applied to the backoff delay.
+	Jitter float64
+
+	// RetryableStatus is the list of HTTP status codes to be retried.
+	RetryableStatus []int
+
+	// RetryCommand enables retrying Command, which is not idempotent.
+	RetryCommand bool
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewClient.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// NoRetry returns the retry policy that never retries.
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// WithRetryPolicy replaces the retry policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+var (
+	jitterMutex  sync.Mutex
+	jitterSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func (p RetryPolicy) retryableStatus(status int) bool {
+	for _, s := range p.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay calculates the wait time before the next attempt.
+// The Retry-After header takes precedence over the exponential backoff, but is still capped by MaxDelay.
+func (p RetryPolicy) delay(attempt int, retryAfter string) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter); ok {
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return d
+	}
+	d := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		jitterMutex.Lock()
+		r := jitterSource.Float64()
+		jitterMutex.Unlock()
+		d -= d * math.Min(p.Jitter, 1) * r
+	}
+	return time.Duration(d)
+}
+
+// parseRetryAfter parses the Retry-After header value in either delay-seconds or HTTP-date form.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(value); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,155 @@
+package kaginawa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetryOnServiceUnavailable(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(`{"id":"f0:18:98:eb:c7:27"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithRetryPolicy(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.FindNode(context.Background(), "f0:18:98:eb:c7:27"); err != nil {
+		t.Fatalf("unexpexted error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected %d attempts, got %d", 3, count)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithRetryPolicy(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.ListAliveNodes(context.Background(), 0)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.Attempts != 3 {
+		t.Errorf("Attempts expected %d, got %d", 3, apiErr.Attempts)
+	}
+	if count != 3 {
+		t.Errorf("expected %d requests, got %d", 3, count)
+	}
+}
+
+func TestRetryTransportFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithRetryPolicy(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.ListAliveNodes(context.Background(), 0)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected *RequestError, got %v", err)
+	}
+	if reqErr.Attempts != 3 {
+		t.Errorf("Attempts expected %d, got %d", 3, reqErr.Attempts)
+	}
+	if reqErr.Method != http.MethodGet || reqErr.Err == nil {
+		t.Errorf("unexpected error: %+v", reqErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.ListAliveNodes(ctx, 0)
+	if !errors.As(err, &reqErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected *RequestError wrapping context.Canceled, got %v", err)
+	}
+}
+
+func TestRetryCommandOptIn(t *testing.T) {
+	tests := []struct {
+		retryCommand bool
+		expected     int32
+	}{
+		{retryCommand: false, expected: 1},
+		{retryCommand: true, expected: 2},
+	}
+	for i, d := range tests {
+		var count int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&count, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("command") != "uptime" {
+				t.Errorf("test %d: unexpected form: %v", i, r.PostForm)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		policy := testRetryPolicy()
+		policy.RetryCommand = d.retryCommand
+		client, err := NewClient(ts.URL, testAPIKey, WithRetryPolicy(policy))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = client.Command(context.Background(), "f0:18:98:eb:c7:27", "uptime", "pi", "", "raspberry", 0)
+		ts.Close()
+		if count != d.expected {
+			t.Errorf("test %d: expected %d requests, got %d", i, d.expected, count)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt    int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{attempt: 1, expected: 100 * time.Millisecond},
+		{attempt: 2, expected: 200 * time.Millisecond},
+		{attempt: 3, expected: 400 * time.Millisecond},
+		{attempt: 5, expected: time.Second},
+		{attempt: 1, retryAfter: "3", expected: time.Second},
+		{attempt: 1, retryAfter: "0", expected: 0},
+		{attempt: 1, retryAfter: "invalid", expected: 100 * time.Millisecond},
+	}
+	for i, d := range tests {
+		actual := policy.delay(d.attempt, d.retryAfter)
+		if d.expected != actual {
+			t.Errorf("test %d: delay() expected %v, got %v", i, d.expected, actual)
+		}
+	}
+	unlimited := RetryPolicy{BaseDelay: 100 * time.Millisecond}
+	if actual := unlimited.delay(1, "3600"); actual != time.Hour {
+		t.Errorf("delay() without MaxDelay expected %v, got %v", time.Hour, actual)
+	}
+}
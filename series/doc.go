@@ -0,0 +1,2 @@
+// Package series aggregates report histories into time buckets with summary statistics and detects reporting gaps.
+package series
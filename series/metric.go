@@ -0,0 +1,37 @@
+package series
+
+import "github.com/kaginawa/kaginawa-sdk-go"
+
+// Metric is a numeric field of the report.
+type Metric string
+
+// Supported metrics.
+const (
+	RTTMillis     Metric = "rtt_ms"
+	UploadKBPS    Metric = "upload_kbps"
+	DownloadKBPS  Metric = "download_kbps"
+	DiskUsedBytes Metric = "disk_used_bytes"
+	GenMillis     Metric = "gen_ms"
+)
+
+// DefaultMetrics is the list of all supported metrics.
+var DefaultMetrics = []Metric{RTTMillis, UploadKBPS, DownloadKBPS, DiskUsedBytes, GenMillis}
+
+// Value extracts the metric from the report.
+// It returns false if the report has no measurement, such as zero RTT of a report without network check.
+func (m Metric) Value(r kaginawa.Report) (float64, bool) {
+	switch m {
+	case RTTMillis:
+		return float64(r.RTTMillis), r.RTTMillis > 0
+	case UploadKBPS:
+		return float64(r.UploadKBPS), r.UploadKBPS > 0
+	case DownloadKBPS:
+		return float64(r.DownloadKBPS), r.DownloadKBPS > 0
+	case DiskUsedBytes:
+		return float64(r.DiskUsedBytes), r.DiskTotalBytes > 0
+	case GenMillis:
+		return float64(r.GenMillis), true
+	default:
+		return 0, false
+	}
+}
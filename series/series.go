@@ -0,0 +1,222 @@
+package series
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+)
+
+// DefaultGapTolerance is the default ratio of the report interval to tolerate before treating as a gap.
+const DefaultGapTolerance = 1.5
+
+// Options configures the aggregation.
+type Options struct {
+	// Resolution is the width of each bucket such as time.Hour or 24 * time.Hour. Required.
+	Resolution time.Duration
+
+	// Metrics is the list of metrics to aggregate. Nil means DefaultMetrics.
+	Metrics []Metric
+
+	// GapTolerance is the ratio of the report interval to tolerate. Zero means DefaultGapTolerance.
+	GapTolerance float64
+
+	// Location aligns buckets of up to a day to the local midnight. Nil means UTC.
+	Location *time.Location
+}
+
+// Bucket is the aggregation of reports in [Start, End).
+type Bucket struct {
+	Start   time.Time
+	End     time.Time
+	Reports int
+	Metrics map[Metric]Stats
+}
+
+// Gap is the period that reports were expected but missing.
+type Gap struct {
+	// Start is the server time of the last report before the gap.
+	Start time.Time
+
+	// End is the server time of the first report after the gap.
+	End time.Time
+
+	// Interval is the report interval expected from the Trigger of the preceding reports.
+	Interval time.Duration
+
+	// Missing is the estimated number of missing reports.
+	Missing int
+
+	// Restarted reports whether the report after the gap was sent by the kaginawa process start.
+	Restarted bool
+}
+
+// Series is the result of the aggregation.
+type Series struct {
+	Resolution time.Duration
+	Buckets    []Bucket
+	Gaps       []Gap
+}
+
+// Aggregator accumulates reports in any order and builds the series.
+type Aggregator struct {
+	opts    Options
+	metrics []Metric
+	buckets map[int64]*builder
+	points  []point
+}
+
+type builder struct {
+	start   time.Time
+	reports int
+	samples map[Metric][]float64
+}
+
+type point struct {
+	time    int64
+	trigger int
+}
+
+// NewAggregator creates an aggregator.
+func NewAggregator(opts Options) (*Aggregator, error) {
+	if opts.Resolution < time.Second {
+		return nil, errors.New("most specify a resolution of one second or longer")
+	}
+	if opts.GapTolerance <= 0 {
+		opts.GapTolerance = DefaultGapTolerance
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	metrics := opts.Metrics
+	if metrics == nil {
+		metrics = DefaultMetrics
+	}
+	return &Aggregator{opts: opts, metrics: metrics, buckets: map[int64]*builder{}}, nil
+}
+
+// Add adds the report. Reports without ServerTime are ignored.
+func (a *Aggregator) Add(r kaginawa.Report) {
+	if r.ServerTime <= 0 {
+		return
+	}
+	start := a.bucketStart(r.Timestamp())
+	b, ok := a.buckets[start.Unix()]
+	if !ok {
+		b = &builder{start: start, samples: map[Metric][]float64{}}
+		a.buckets[start.Unix()] = b
+	}
+	b.reports++
+	for _, m := range a.metrics {
+		if v, ok := m.Value(r); ok {
+			b.samples[m] = append(b.samples[m], v)
+		}
+	}
+	a.points = append(a.points, point{time: r.ServerTime, trigger: r.Trigger})
+}
+
+// Series builds the series from the added reports. Buckets without reports are omitted.
+func (a *Aggregator) Series() *Series {
+	s := &Series{Resolution: a.opts.Resolution}
+	keys := make([]int64, 0, len(a.buckets))
+	for k := range a.buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, k := range keys {
+		b := a.buckets[k]
+		bucket := Bucket{
+			Start:   b.start,
+			End:     a.bucketEnd(b.start),
+			Reports: b.reports,
+			Metrics: map[Metric]Stats{},
+		}
+		for m, samples := range b.samples {
+			bucket.Metrics[m] = Summarize(samples)
+		}
+		s.Buckets = append(s.Buckets, bucket)
+	}
+	s.Gaps = a.gaps()
+	return s
+}
+
+// gaps walks the reports in time order and reports intervals longer than expected.
+// The expected interval is taken from the latest interval-triggered report, so reports of
+// SSH connection (-1) and process start (0) do not change it.
+func (a *Aggregator) gaps() []Gap {
+	points := make([]point, len(a.points))
+	copy(points, a.points)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].time < points[j].time })
+	var gaps []Gap
+	var interval int64
+	for i, p := range points {
+		if i > 0 && interval > 0 {
+			prev := points[i-1]
+			elapsed := p.time - prev.time
+			if float64(elapsed) > float64(interval)*a.opts.GapTolerance {
+				gaps = append(gaps, Gap{
+					Start:     time.Unix(prev.time, 0).In(a.opts.Location),
+					End:       time.Unix(p.time, 0).In(a.opts.Location),
+					Interval:  time.Duration(interval) * time.Second,
+					Missing:   int((elapsed+interval/2)/interval) - 1,
+					Restarted: p.trigger == 0,
+				})
+			}
+		}
+		if p.trigger > 0 {
+			interval = int64(p.trigger) * 60
+		}
+	}
+	return gaps
+}
+
+// bucketStart returns the start time of the bucket that contains t.
+func (a *Aggregator) bucketStart(t time.Time) time.Time {
+	t = t.In(a.opts.Location)
+	if a.opts.Resolution <= 24*time.Hour {
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, a.opts.Location)
+		return midnight.Add(t.Sub(midnight).Truncate(a.opts.Resolution))
+	}
+	resolution := int64(a.opts.Resolution / time.Second)
+	return time.Unix(t.Unix()-t.Unix()%resolution, 0).In(a.opts.Location)
+}
+
+// bucketEnd returns the end time of the bucket. Buckets never cross the local midnight.
+func (a *Aggregator) bucketEnd(start time.Time) time.Time {
+	end := start.Add(a.opts.Resolution)
+	if a.opts.Resolution <= 24*time.Hour {
+		next := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, a.opts.Location)
+		if end.After(next) {
+			end = next
+		}
+	}
+	return end
+}
+
+// Aggregate builds the series from the reports.
+func Aggregate(reports []kaginawa.Report, opts Options) (*Series, error) {
+	a, err := NewAggregator(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reports {
+		a.Add(r)
+	}
+	return a.Series(), nil
+}
+
+// AggregateIterator builds the series by consuming the iterator.
+func AggregateIterator(it kaginawa.ReportIterator, opts Options) (*Series, error) {
+	a, err := NewAggregator(opts)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		a.Add(it.Report())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return a.Series(), nil
+}
@@ -0,0 +1,137 @@
+package series
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	"github.com/kaginawa/kaginawa-sdk-go/kaginawatest"
+)
+
+const testNodeID = "b8:27:eb:36:83:e0"
+
+// base is 2020-04-20T00:00:00Z.
+var base = time.Date(2020, 4, 20, 0, 0, 0, 0, time.UTC).Unix()
+
+func testReport(offset time.Duration, trigger int, rtt int64) kaginawa.Report {
+	return kaginawa.Report{
+		ID:             testNodeID,
+		Trigger:        trigger,
+		ServerTime:     base + int64(offset/time.Second),
+		RTTMillis:      rtt,
+		GenMillis:      10,
+		DiskTotalBytes: 1000,
+		DiskUsedBytes:  500,
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	reports := []kaginawa.Report{
+		testReport(0, 0, 10),
+		testReport(10*time.Minute, 10, 20),
+		testReport(20*time.Minute, 10, 30),
+		testReport(70*time.Minute, 10, 0), // no network measurement
+		testReport(80*time.Minute, 10, 50),
+	}
+	s, err := Aggregate(reports, Options{Resolution: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Buckets) != 2 {
+		t.Fatalf("expected %d buckets, got %d", 2, len(s.Buckets))
+	}
+	first := s.Buckets[0]
+	if first.Start.Unix() != base || first.End.Unix() != base+3600 || first.Reports != 3 {
+		t.Errorf("unexpected bucket: %+v", first)
+	}
+	if rtt := first.Metrics[RTTMillis]; rtt.Count != 3 || rtt.Min != 10 || rtt.Max != 30 || rtt.Mean != 20 {
+		t.Errorf("unexpected rtt stats: %+v", rtt)
+	}
+	second := s.Buckets[1]
+	if second.Reports != 2 || second.Metrics[RTTMillis].Count != 1 || second.Metrics[GenMillis].Count != 2 {
+		t.Errorf("unexpected bucket: %+v", second)
+	}
+	if _, ok := second.Metrics[UploadKBPS]; ok {
+		t.Errorf("expected no upload stats, got %+v", second.Metrics[UploadKBPS])
+	}
+}
+
+func TestAggregateDailyInLocation(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	reports := []kaginawa.Report{
+		testReport(14*time.Hour, 10, 10), // 23:00 JST
+		testReport(16*time.Hour, 10, 10), // 01:00 JST, next day
+	}
+	s, err := Aggregate(reports, Options{Resolution: 24 * time.Hour, Location: jst, Metrics: []Metric{RTTMillis}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Buckets) != 2 {
+		t.Fatalf("expected %d buckets, got %d", 2, len(s.Buckets))
+	}
+	expected := time.Date(2020, 4, 20, 0, 0, 0, 0, jst)
+	if !s.Buckets[0].Start.Equal(expected) || !s.Buckets[0].End.Equal(expected.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected bucket range: %v - %v", s.Buckets[0].Start, s.Buckets[0].End)
+	}
+	if len(s.Buckets[0].Metrics) != 1 {
+		t.Errorf("expected only rtt, got %v", s.Buckets[0].Metrics)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	reports := []kaginawa.Report{
+		testReport(0, 0, 10),
+		testReport(time.Minute, -1, 10), // ssh connected
+		testReport(5*time.Minute, 5, 10),
+		testReport(10*time.Minute, 5, 10),
+		testReport(14*time.Minute, 5, 10), // jitter within tolerance
+		testReport(34*time.Minute, 5, 10), // 3 reports missing
+		testReport(90*time.Minute, 0, 10), // restarted
+	}
+	s, err := Aggregate(reports, Options{Resolution: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Gaps) != 2 {
+		t.Fatalf("expected %d gaps, got %+v", 2, s.Gaps)
+	}
+	gap := s.Gaps[0]
+	if gap.Start.Unix() != base+14*60 || gap.End.Unix() != base+34*60 || gap.Interval != 5*time.Minute ||
+		gap.Missing != 3 || gap.Restarted {
+		t.Errorf("unexpected gap: %+v", gap)
+	}
+	if !s.Gaps[1].Restarted || s.Gaps[1].Missing != 10 {
+		t.Errorf("unexpected gap: %+v", s.Gaps[1])
+	}
+}
+
+func TestAggregateInvalidResolution(t *testing.T) {
+	if _, err := Aggregate(nil, Options{}); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestAggregateIterator(t *testing.T) {
+	s := kaginawatest.NewServer()
+	defer s.Close()
+	for i := 0; i < 6; i++ {
+		s.AddReport(testReport(time.Duration(i)*30*time.Minute, 30, int64(10*(i+1))))
+	}
+	client, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	it := client.Histories(context.Background(), testNodeID, base, base+3*3600, time.Hour)
+	defer it.Close()
+	series, err := AggregateIterator(it, Options{Resolution: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(series.Buckets) != 3 || len(series.Gaps) != 0 {
+		t.Fatalf("unexpected series: %+v", series)
+	}
+	if rtt := series.Buckets[2].Metrics[RTTMillis]; rtt.Min != 50 || rtt.Max != 60 {
+		t.Errorf("unexpected rtt stats: %+v", rtt)
+	}
+}
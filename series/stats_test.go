@@ -0,0 +1,35 @@
+package series
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		input    []float64
+		expected Stats
+	}{
+		{
+			input:    nil,
+			expected: Stats{},
+		},
+		{
+			input:    []float64{42},
+			expected: Stats{Count: 1, Min: 42, Max: 42, Mean: 42, P50: 42, P95: 42, P99: 42},
+		},
+		{
+			input:    []float64{5, 1, 4, 2, 3},
+			expected: Stats{Count: 5, Min: 1, Max: 5, Mean: 3, P50: 3, P95: 4.8, P99: 4.96},
+		},
+	}
+	for i, test := range tests {
+		actual := Summarize(test.input)
+		if !approx(actual, test.expected) {
+			t.Errorf("test %d: expected %+v, got %+v", i, test.expected, actual)
+		}
+	}
+}
+
+func approx(a, b Stats) bool {
+	eq := func(x, y float64) bool { return x-y < 1e-9 && y-x < 1e-9 }
+	return a.Count == b.Count && eq(a.Min, b.Min) && eq(a.Max, b.Max) && eq(a.Mean, b.Mean) &&
+		eq(a.P50, b.P50) && eq(a.P95, b.P95) && eq(a.P99, b.P99)
+}
@@ -0,0 +1,186 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	xssh "golang.org/x/crypto/ssh"
+)
+
+// remoteForwardHost is the host that the reverse-forwarded port of the node is bound on the SSH server.
+const remoteForwardHost = "localhost"
+
+// DefaultTimeout is the default time limit of establishing each SSH connection.
+const DefaultTimeout = 30 * time.Second
+
+// Dialer opens SSH connections to nodes through the Kaginawa SSH server.
+type Dialer struct {
+	// Client is the Kaginawa client used to resolve nodes and SSH servers.
+	Client *kaginawa.Client
+
+	// ServerHostKeyCallback verifies the host key of the Kaginawa SSH server. Required.
+	ServerHostKeyCallback xssh.HostKeyCallback
+
+	// NodeHostKeyCallback verifies the host key of the node. Required.
+	NodeHostKeyCallback xssh.HostKeyCallback
+
+	// NodeUser is the login user of the node.
+	NodeUser string
+
+	// NodeAuth is the authentication methods of the node.
+	NodeAuth []xssh.AuthMethod
+
+	// Timeout is the time limit of establishing each SSH connection. Zero means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Dial resolves the node and its SSH server by id, then opens an SSH connection to the node.
+// Closing the returned client also closes the connection to the SSH server.
+func (d *Dialer) Dial(ctx context.Context, nodeID string) (*xssh.Client, error) {
+	if d.Client == nil {
+		return nil, errors.New("most specify a kaginawa client")
+	}
+	report, err := d.Client.FindNode(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node: %w", err)
+	}
+	if len(report.SSHServerHost) == 0 || report.SSHRemotePort <= 0 {
+		return nil, fmt.Errorf("node %s is not connected to any ssh server", nodeID)
+	}
+	server, err := d.Client.FindSSHServerByHostname(ctx, report.SSHServerHost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ssh server: %w", err)
+	}
+	return d.DialNode(ctx, *report, *server)
+}
+
+// DialNode opens an SSH connection to the node through the SSH server without querying Kaginawa Server.
+// Closing the returned client also closes the connection to the SSH server.
+func (d *Dialer) DialNode(ctx context.Context, report kaginawa.Report, server kaginawa.SSHServer) (*xssh.Client, error) {
+	if d.ServerHostKeyCallback == nil {
+		return nil, errors.New("most specify a server host key callback")
+	}
+	if d.NodeHostKeyCallback == nil {
+		return nil, errors.New("most specify a node host key callback")
+	}
+	if report.SSHRemotePort <= 0 {
+		return nil, fmt.Errorf("node %s is not connected to any ssh server", report.ID)
+	}
+	serverConfig, err := ServerConfig(server, d.ServerHostKeyCallback)
+	if err != nil {
+		return nil, err
+	}
+	serverAddr := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", serverAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect ssh server %s: %w", serverAddr, err)
+	}
+	jump, err := d.handshake(ctx, conn, serverAddr, serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handshake with ssh server %s: %w", serverAddr, err)
+	}
+	nodeAddr := net.JoinHostPort(remoteForwardHost, strconv.Itoa(report.SSHRemotePort))
+	tunnel, err := jump.Dial("tcp", nodeAddr)
+	if err != nil {
+		_ = jump.Close()
+		return nil, fmt.Errorf("failed to connect node %s through %s: %w", report.ID, serverAddr, err)
+	}
+	nodeConfig := &xssh.ClientConfig{
+		User:            d.NodeUser,
+		Auth:            d.NodeAuth,
+		HostKeyCallback: d.NodeHostKeyCallback,
+	}
+	client, err := d.handshake(ctx, &jumpConn{Conn: tunnel, jump: jump}, nodeAddr, nodeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handshake with node %s: %w", report.ID, err)
+	}
+	return client, nil
+}
+
+// ServerConfig builds the client config to log in to the SSH server entry.
+func ServerConfig(server kaginawa.SSHServer, hostKeyCallback xssh.HostKeyCallback) (*xssh.ClientConfig, error) {
+	var auth []xssh.AuthMethod
+	if len(server.Key) > 0 {
+		signer, err := xssh.ParsePrivateKey([]byte(server.Key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key of %s: %w", server.Host, err)
+		}
+		auth = append(auth, xssh.PublicKeys(signer))
+	}
+	if len(server.Password) > 0 {
+		auth = append(auth, xssh.Password(server.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("no credentials for ssh server %s", server.Host)
+	}
+	return &xssh.ClientConfig{
+		User:            server.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+func (d *Dialer) timeout() time.Duration {
+	if d.Timeout > 0 {
+		return d.Timeout
+	}
+	return DefaultTimeout
+}
+
+// handshake establishes the SSH connection over conn, aborting when the context is done or timed out.
+// The conn is closed on failure.
+func (d *Dialer) handshake(ctx context.Context, conn net.Conn, addr string, config *xssh.ClientConfig) (*xssh.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout())
+	defer cancel()
+	stop := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-stop:
+		}
+	}()
+	c, chans, reqs, err := xssh.NewClientConn(conn, addr, config)
+	close(stop)
+	<-exited
+	if err == nil && ctx.Err() != nil {
+		_ = c.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return xssh.NewClient(c, chans, reqs), nil
+}
+
+// jumpConn is the tunneled connection that closes the SSH server connection together.
+type jumpConn struct {
+	net.Conn
+	jump *xssh.Client
+	once sync.Once
+	err  error
+}
+
+// Close closes the tunnel and the SSH server connection.
+func (c *jumpConn) Close() error {
+	c.once.Do(func() {
+		c.err = c.Conn.Close()
+		if err := c.jump.Close(); err != nil && c.err == nil {
+			c.err = err
+		}
+	})
+	return c.err
+}
@@ -0,0 +1,57 @@
+package ssh
+
+import (
+	"context"
+	"testing"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+func TestDial(t *testing.T) {
+	env := newTestEnv(t)
+	client := env.dial(t)
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	defer session.Close()
+	output, err := session.Output("hostname")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "exec: hostname" {
+		t.Errorf("output expected %s, got %s", "exec: hostname", output)
+	}
+}
+
+func TestDialWithWrongServerHostKey(t *testing.T) {
+	env := newTestEnv(t)
+	env.dialer.ServerHostKeyCallback = xssh.FixedHostKey(env.node.signer.PublicKey())
+	if _, err := env.dialer.Dial(context.Background(), testNodeID); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestDialWithWrongNodeHostKey(t *testing.T) {
+	env := newTestEnv(t)
+	env.dialer.NodeHostKeyCallback = xssh.FixedHostKey(env.jump.signer.PublicKey())
+	if _, err := env.dialer.Dial(context.Background(), testNodeID); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestDialWithWrongNodePassword(t *testing.T) {
+	env := newTestEnv(t)
+	env.dialer.NodeAuth = []xssh.AuthMethod{xssh.Password("wrong")}
+	if _, err := env.dialer.Dial(context.Background(), testNodeID); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
+
+func TestDialWithoutHostKeyCallback(t *testing.T) {
+	env := newTestEnv(t)
+	env.dialer.NodeHostKeyCallback = nil
+	if _, err := env.dialer.Dial(context.Background(), testNodeID); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
@@ -0,0 +1,2 @@
+// Package ssh connects to Kaginawa nodes through the reverse-forwarded port on the Kaginawa SSH server.
+package ssh
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+// ForwardStats is the connection accounting of a Forwarder.
+type ForwardStats struct {
+	// Active is the number of connections currently forwarded.
+	Active int64
+
+	// Total is the number of accepted connections.
+	Total int64
+
+	// Failed is the number of connections that could not reach the remote address.
+	Failed int64
+
+	// BytesSent is the number of bytes sent from local to remote.
+	BytesSent int64
+
+	// BytesReceived is the number of bytes received from remote to local.
+	BytesReceived int64
+}
+
+// Forwarder forwards local connections to the remote address through the node.
+type Forwarder struct {
+	listener net.Listener
+	client   *xssh.Client
+	stats    ForwardStats
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Forward opens an SSH connection to the node and listens on localAddr.
+// Each accepted connection is piped to remoteAddr as seen from the node, such as "localhost:80".
+// Forwarding continues until the context is done or Close is called.
+func (d *Dialer) Forward(ctx context.Context, nodeID, localAddr, remoteAddr string) (*Forwarder, error) {
+	client, err := d.Dial(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to listen %s: %w", localAddr, err)
+	}
+	f := &Forwarder{
+		listener: listener,
+		client:   client,
+		conns:    map[net.Conn]struct{}{},
+		done:     make(chan struct{}),
+	}
+	f.wg.Add(1)
+	go f.serve(remoteAddr)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+		case <-f.done:
+		}
+	}()
+	return f, nil
+}
+
+// Addr returns the local listening address.
+func (f *Forwarder) Addr() net.Addr {
+	return f.listener.Addr()
+}
+
+// Stats returns the snapshot of the connection accounting.
+func (f *Forwarder) Stats() ForwardStats {
+	return ForwardStats{
+		Active:        atomic.LoadInt64(&f.stats.Active),
+		Total:         atomic.LoadInt64(&f.stats.Total),
+		Failed:        atomic.LoadInt64(&f.stats.Failed),
+		BytesSent:     atomic.LoadInt64(&f.stats.BytesSent),
+		BytesReceived: atomic.LoadInt64(&f.stats.BytesReceived),
+	}
+}
+
+// Close stops listening, closes all forwarded connections and the SSH connection, then waits for teardown.
+func (f *Forwarder) Close() error {
+	var err error
+	f.once.Do(func() {
+		close(f.done)
+		err = f.listener.Close()
+		f.mu.Lock()
+		for conn := range f.conns {
+			_ = conn.Close()
+		}
+		f.mu.Unlock()
+		f.wg.Wait()
+		if cerr := f.client.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// Done returns a channel that is closed when the forwarder is closing.
+func (f *Forwarder) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *Forwarder) serve(remoteAddr string) {
+	defer f.wg.Done()
+	for {
+		local, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		atomic.AddInt64(&f.stats.Total, 1)
+		if !f.track(local) {
+			_ = local.Close()
+			return
+		}
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			defer f.untrack(local)
+			remote, err := f.client.Dial("tcp", remoteAddr)
+			if err != nil {
+				atomic.AddInt64(&f.stats.Failed, 1)
+				return
+			}
+			if !f.track(remote) {
+				_ = remote.Close()
+				return
+			}
+			defer f.untrack(remote)
+			atomic.AddInt64(&f.stats.Active, 1)
+			defer atomic.AddInt64(&f.stats.Active, -1)
+			f.pipe(local, remote)
+		}()
+	}
+}
+
+// pipe copies both directions until either side is closed.
+func (f *Forwarder) pipe(local, remote net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		n, _ := io.Copy(remote, local)
+		atomic.AddInt64(&f.stats.BytesSent, n)
+		_ = remote.Close()
+	}()
+	n, _ := io.Copy(local, remote)
+	atomic.AddInt64(&f.stats.BytesReceived, n)
+	_ = local.Close()
+	wg.Wait()
+}
+
+// track registers the connection to be closed on teardown. It returns false if already closing.
+func (f *Forwarder) track(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	select {
+	case <-f.done:
+		return false
+	default:
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+func (f *Forwarder) untrack(conn net.Conn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = conn.Close()
+	delete(f.conns, conn)
+}
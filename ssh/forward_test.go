@@ -0,0 +1,105 @@
+package ssh
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func newEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return listener
+}
+
+func TestForward(t *testing.T) {
+	env := newTestEnv(t)
+	echo := newEchoServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	forwarder, err := env.dialer.Forward(ctx, testNodeID, "127.0.0.1:0", echo.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn, err := net.Dial("tcp", forwarder.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect forwarder: %v", err)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("expected %s, got %s", "ping", buf)
+	}
+	stats := forwarder.Stats()
+	if stats.Total != 1 || stats.Active != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	cancel()
+	select {
+	case <-forwarder.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwarder did not stop")
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+	if _, err := conn.Read(buf); err == nil {
+		t.Error("expected forwarded connection to be closed")
+	}
+	if err := forwarder.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stats := forwarder.Stats(); stats.Active != 0 || stats.BytesSent != 4 || stats.BytesReceived != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if _, err := net.Dial("tcp", forwarder.Addr().String()); err == nil {
+		t.Error("expected listener to be closed")
+	}
+}
+
+func TestForwardToUnreachableAddress(t *testing.T) {
+	env := newTestEnv(t)
+	closed := newEchoServer(t)
+	addr := closed.Addr().String()
+	_ = closed.Close()
+	forwarder, err := env.dialer.Forward(context.Background(), testNodeID, "127.0.0.1:0", addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer forwarder.Close()
+	conn, err := net.Dial("tcp", forwarder.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect forwarder: %v", err)
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expected connection to be closed")
+	}
+	if stats := forwarder.Stats(); stats.Failed != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
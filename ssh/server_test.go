@@ -0,0 +1,308 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kaginawa/kaginawa-sdk-go"
+	"github.com/pkg/sftp"
+	xssh "golang.org/x/crypto/ssh"
+)
+
+const (
+	testAPIKey   = "test123"
+	testNodeID   = "b8:27:eb:36:83:e0"
+	testUser     = "pi"
+	testPassword = "raspberry"
+)
+
+// testServer is an in-process SSH server that accepts password authentication,
+// direct-tcpip forwarding, exec, shell and sftp sessions.
+type testServer struct {
+	listener net.Listener
+	signer   xssh.Signer
+	port     int
+}
+
+func newTestServer(t *testing.T, user, password string) *testServer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	signer, err := xssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &testServer{listener: listener, signer: signer, port: listener.Addr().(*net.TCPAddr).Port}
+	config := &xssh.ServerConfig{
+		PasswordCallback: func(conn xssh.ConnMetadata, pw []byte) (*xssh.Permissions, error) {
+			if conn.User() == user && string(pw) == password {
+				return nil, nil
+			}
+			return nil, errors.New("authentication failed")
+		},
+	}
+	config.AddHostKey(signer)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *xssh.ServerConfig) {
+	sc, chans, reqs, err := xssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sc.Close()
+	go xssh.DiscardRequests(reqs)
+	for ch := range chans {
+		switch ch.ChannelType() {
+		case "direct-tcpip":
+			go s.forward(ch)
+		case "session":
+			go s.session(ch)
+		default:
+			_ = ch.Reject(xssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+// forward handles the direct-tcpip channel (RFC 4254 7.2).
+func (s *testServer) forward(ch xssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := xssh.Unmarshal(ch.ExtraData(), &payload); err != nil {
+		_ = ch.Reject(xssh.ConnectionFailed, err.Error())
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		_ = ch.Reject(xssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, reqs, err := ch.Accept()
+	if err != nil {
+		_ = target.Close()
+		return
+	}
+	go xssh.DiscardRequests(reqs)
+	go func() {
+		_, _ = io.Copy(target, channel)
+		_ = target.Close()
+	}()
+	_, _ = io.Copy(channel, target)
+	_ = channel.Close()
+}
+
+// session handles the exec request by echoing the command, the line-based fake shell and the sftp subsystem.
+func (s *testServer) session(ch xssh.NewChannel) {
+	channel, reqs, err := ch.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+	state := &shellState{env: map[string]string{}}
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req":
+			var payload struct {
+				Term   string
+				Width  uint32
+				Height uint32
+				PixelW uint32
+				PixelH uint32
+				Modes  string
+			}
+			if err := xssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			state.mu.Lock()
+			state.term, state.width, state.height = payload.Term, payload.Width, payload.Height
+			state.mu.Unlock()
+			_ = req.Reply(true, nil)
+		case "window-change":
+			var payload struct {
+				Width  uint32
+				Height uint32
+				PixelW uint32
+				PixelH uint32
+			}
+			if err := xssh.Unmarshal(req.Payload, &payload); err != nil {
+				continue
+			}
+			state.mu.Lock()
+			state.width, state.height = payload.Width, payload.Height
+			state.mu.Unlock()
+		case "env":
+			var payload struct{ Name, Value string }
+			if err := xssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			state.mu.Lock()
+			state.env[payload.Name] = payload.Value
+			state.mu.Unlock()
+			_ = req.Reply(true, nil)
+		case "shell":
+			_ = req.Reply(true, nil)
+			go state.run(channel)
+		case "exec":
+			var payload struct{ Command string }
+			if err := xssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			_, _ = channel.Write([]byte("exec: " + payload.Command))
+			sendExitStatus(channel, 0)
+			return
+		case "subsystem":
+			var payload struct{ Name string }
+			if err := xssh.Unmarshal(req.Payload, &payload); err != nil || payload.Name != "sftp" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			_ = server.Serve()
+			return
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+// shellState is the pseudo terminal and environment of the fake shell.
+type shellState struct {
+	mu     sync.Mutex
+	term   string
+	width  uint32
+	height uint32
+	env    map[string]string
+}
+
+// run executes the fake shell that understands "size", "term", "env NAME", "err TEXT" and "exit N",
+// and echoes other lines.
+func (s *shellState) run(channel xssh.Channel) {
+	defer channel.Close()
+	scanner := bufio.NewScanner(channel)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		s.mu.Lock()
+		switch {
+		case line == "size":
+			fmt.Fprintf(channel, "%dx%d\n", s.width, s.height)
+		case line == "term":
+			fmt.Fprintf(channel, "%s\n", s.term)
+		case strings.HasPrefix(line, "env "):
+			fmt.Fprintf(channel, "%s\n", s.env[strings.TrimPrefix(line, "env ")])
+		case strings.HasPrefix(line, "err "):
+			fmt.Fprintf(channel.Stderr(), "%s\n", strings.TrimPrefix(line, "err "))
+		case strings.HasPrefix(line, "exit "):
+			s.mu.Unlock()
+			status, _ := strconv.Atoi(strings.TrimPrefix(line, "exit "))
+			sendExitStatus(channel, uint32(status))
+			return
+		default:
+			fmt.Fprintf(channel, "echo: %s\n", line)
+		}
+		s.mu.Unlock()
+	}
+	sendExitStatus(channel, 0)
+}
+
+func sendExitStatus(channel xssh.Channel, status uint32) {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, status)
+	_, _ = channel.SendRequest("exit-status", false, payload)
+}
+
+// newTestKaginawa starts a fake Kaginawa Server that serves the node and the SSH server entry.
+func newTestKaginawa(t *testing.T, report kaginawa.Report, server kaginawa.SSHServer) *kaginawa.Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nodes/"+report.ID, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	})
+	mux.HandleFunc("/servers/"+server.Host, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(server); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	client, err := kaginawa.NewClient(ts.URL, testAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+// testEnv is a pair of the SSH server (jump) and the node behind it.
+type testEnv struct {
+	jump   *testServer
+	node   *testServer
+	dialer *Dialer
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	jump := newTestServer(t, "kaginawa", "test-pw")
+	node := newTestServer(t, testUser, testPassword)
+	report := kaginawa.Report{ID: testNodeID, SSHServerHost: "127.0.0.1", SSHRemotePort: node.port}
+	server := kaginawa.SSHServer{Host: "127.0.0.1", Port: jump.port, User: "kaginawa", Password: "test-pw"}
+	return &testEnv{
+		jump: jump,
+		node: node,
+		dialer: &Dialer{
+			Client:                newTestKaginawa(t, report, server),
+			ServerHostKeyCallback: xssh.FixedHostKey(jump.signer.PublicKey()),
+			NodeHostKeyCallback:   xssh.FixedHostKey(node.signer.PublicKey()),
+			NodeUser:              testUser,
+			NodeAuth:              []xssh.AuthMethod{xssh.Password(testPassword)},
+		},
+	}
+}
+
+func (e *testEnv) dial(t *testing.T) *xssh.Client {
+	client, err := e.dialer.Dial(context.Background(), testNodeID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
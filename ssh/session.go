@@ -0,0 +1,184 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+// DefaultTerm is the default terminal type of the pseudo terminal.
+const DefaultTerm = "xterm-256color"
+
+// Terminal is the pseudo terminal requested for the shell.
+type Terminal struct {
+	// Term is the terminal type such as "xterm". Empty means DefaultTerm.
+	Term string
+
+	// Width is the number of columns.
+	Width int
+
+	// Height is the number of rows.
+	Height int
+
+	// Modes is the terminal modes. Nil means echo enabled with 14.4kbaud.
+	Modes xssh.TerminalModes
+}
+
+// ShellOptions configures the interactive shell.
+type ShellOptions struct {
+	// Terminal is the pseudo terminal of the shell. Nil runs the shell without a pseudo terminal.
+	Terminal *Terminal
+
+	// Env is the environment variables sent before starting the shell.
+	// Servers may reject them by AcceptEnv setting of sshd.
+	Env map[string]string
+
+	// Stdin is the input of the shell. Nil makes Session.Stdin available.
+	Stdin io.Reader
+
+	// Stdout is the output of the shell. Nil makes Session.Stdout available.
+	Stdout io.Writer
+
+	// Stderr is the error output of the shell. Nil makes Session.Stderr available.
+	// With a pseudo terminal, the error output is usually merged into Stdout by the node.
+	Stderr io.Writer
+}
+
+// Session is the interactive shell running on the node.
+type Session struct {
+	// Stdin is the input pipe of the shell, available if ShellOptions.Stdin is nil.
+	Stdin io.WriteCloser
+
+	// Stdout is the output pipe of the shell, available if ShellOptions.Stdout is nil.
+	Stdout io.Reader
+
+	// Stderr is the error output pipe of the shell, available if ShellOptions.Stderr is nil.
+	Stderr io.Reader
+
+	client  *xssh.Client
+	session *xssh.Session
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// Shell opens an SSH connection to the node and starts the login shell.
+// The session is closed when the context is done.
+func (d *Dialer) Shell(ctx context.Context, nodeID string, opts ShellOptions) (*Session, error) {
+	client, err := d.Dial(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := startShell(client, opts)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = s.Close()
+		case <-s.done:
+		}
+	}()
+	return s, nil
+}
+
+func startShell(client *xssh.Client, opts ShellOptions) (*Session, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+	s := &Session{client: client, session: session, done: make(chan struct{})}
+	if err := s.setup(opts); err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	if err := session.Shell(); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("failed to start shell: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Session) setup(opts ShellOptions) error {
+	for k, v := range opts.Env {
+		if err := s.session.Setenv(k, v); err != nil {
+			return fmt.Errorf("failed to set %s: %w", k, err)
+		}
+	}
+	if t := opts.Terminal; t != nil {
+		term := t.Term
+		if len(term) == 0 {
+			term = DefaultTerm
+		}
+		modes := t.Modes
+		if modes == nil {
+			modes = xssh.TerminalModes{
+				xssh.ECHO:          1,
+				xssh.TTY_OP_ISPEED: 14400,
+				xssh.TTY_OP_OSPEED: 14400,
+			}
+		}
+		if err := s.session.RequestPty(term, t.Height, t.Width, modes); err != nil {
+			return fmt.Errorf("failed to request pty: %w", err)
+		}
+	}
+	var err error
+	if opts.Stdin != nil {
+		s.session.Stdin = opts.Stdin
+	} else if s.Stdin, err = s.session.StdinPipe(); err != nil {
+		return err
+	}
+	if opts.Stdout != nil {
+		s.session.Stdout = opts.Stdout
+	} else if s.Stdout, err = s.session.StdoutPipe(); err != nil {
+		return err
+	}
+	if opts.Stderr != nil {
+		s.session.Stderr = opts.Stderr
+	} else if s.Stderr, err = s.session.StderrPipe(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Resize notifies the node of the new terminal size.
+func (s *Session) Resize(width, height int) error {
+	return s.session.WindowChange(height, width)
+}
+
+// Signal sends the signal to the shell. Many servers ignore it.
+func (s *Session) Signal(sig xssh.Signal) error {
+	return s.session.Signal(sig)
+}
+
+// Wait waits for the shell to exit and returns the exit status.
+// A non-zero exit status is not an error; an error is returned only if the session was lost
+// or the node did not report the exit status.
+func (s *Session) Wait() (int, error) {
+	err := s.session.Wait()
+	var exitErr *xssh.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitStatus(), nil
+	default:
+		return -1, err
+	}
+}
+
+// Close closes the session and the SSH connection.
+func (s *Session) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		_ = s.session.Close()
+		s.err = s.client.Close()
+	})
+	return s.err
+}
@@ -0,0 +1,121 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShell(t *testing.T) {
+	env := newTestEnv(t)
+	session, err := env.dialer.Shell(context.Background(), testNodeID, ShellOptions{
+		Terminal: &Terminal{Width: 80, Height: 24},
+		Env:      map[string]string{"LANG": "C.UTF-8"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer session.Close()
+	stdout := bufio.NewReader(session.Stdout)
+	stderr := bufio.NewReader(session.Stderr)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"hello", "echo: hello\n"},
+		{"term", DefaultTerm + "\n"},
+		{"size", "80x24\n"},
+		{"env LANG", "C.UTF-8\n"},
+	}
+	for _, test := range tests {
+		if _, err := session.Stdin.Write([]byte(test.input + "\n")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		line, err := stdout.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if line != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.input, test.expected, line)
+		}
+	}
+
+	if err := session.Resize(120, 40); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// window-change has no reply, so poll until the node applies it.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, _ = session.Stdin.Write([]byte("size\n"))
+		line, err := stdout.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if line == "120x40\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %q, got %q", "120x40\n", line)
+		}
+	}
+
+	_, _ = session.Stdin.Write([]byte("err oops\n"))
+	line, err := stderr.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if line != "oops\n" {
+		t.Errorf("expected %q, got %q", "oops\n", line)
+	}
+
+	_, _ = session.Stdin.Write([]byte("exit 3\n"))
+	status, err := session.Wait()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != 3 {
+		t.Errorf("expected exit status %d, got %d", 3, status)
+	}
+}
+
+func TestShellWithStreams(t *testing.T) {
+	env := newTestEnv(t)
+	var stdout bytes.Buffer
+	session, err := env.dialer.Shell(context.Background(), testNodeID, ShellOptions{
+		Stdin:  strings.NewReader("hello\nworld\n"),
+		Stdout: &stdout,
+		Stderr: ioutil.Discard,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer session.Close()
+	status, err := session.Wait()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != 0 {
+		t.Errorf("expected exit status %d, got %d", 0, status)
+	}
+	expected := "echo: hello\necho: world\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestShellCanceled(t *testing.T) {
+	env := newTestEnv(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	session, err := env.dialer.Shell(ctx, testNodeID, ShellOptions{Stdout: ioutil.Discard, Stderr: ioutil.Discard})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cancel()
+	if _, err := session.Wait(); err == nil {
+		t.Error("expected error, got nil.")
+	}
+}
@@ -0,0 +1,222 @@
+package ssh
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/sftp"
+)
+
+// ErrChecksumMismatch is returned when the transferred file differs from the source.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// TransferOptions configures PutFile and GetFile.
+type TransferOptions struct {
+	// Progress is called after each chunk with the transferred and total bytes.
+	Progress func(transferred, total int64)
+
+	// Resume continues the transfer from the size of the existing destination file
+	// if it is smaller than the source.
+	Resume bool
+
+	// Verify compares SHA-256 checksums of the source and the destination after the transfer.
+	Verify bool
+}
+
+// PutFile uploads the local file to the node over SFTP.
+func (d *Dialer) PutFile(ctx context.Context, nodeID, localPath, remotePath string, opts TransferOptions) error {
+	return d.withSFTP(ctx, nodeID, func(client *sftp.Client) error {
+		src, err := os.Open(localPath)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", localPath, err)
+		}
+		defer src.Close()
+		info, err := src.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", localPath, err)
+		}
+		var offset int64
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if opts.Resume {
+			if remote, err := client.Stat(remotePath); err == nil && remote.Size() <= info.Size() {
+				offset = remote.Size()
+				flags = os.O_WRONLY
+			}
+		}
+		dst, err := client.OpenFile(remotePath, flags)
+		if err != nil {
+			return fmt.Errorf("failed to open remote %s: %w", remotePath, err)
+		}
+		defer dst.Close()
+		if err := transfer(ctx, src, dst, offset, info.Size(), opts.Progress); err != nil {
+			return err
+		}
+		if err := dst.Close(); err != nil {
+			return fmt.Errorf("failed to close remote %s: %w", remotePath, err)
+		}
+		if err := client.Chmod(remotePath, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to chmod remote %s: %w", remotePath, err)
+		}
+		if opts.Verify {
+			remote, err := client.Open(remotePath)
+			if err != nil {
+				return fmt.Errorf("failed to open remote %s: %w", remotePath, err)
+			}
+			defer remote.Close()
+			return verify(src, remote)
+		}
+		return nil
+	})
+}
+
+// GetFile downloads the remote file of the node over SFTP.
+func (d *Dialer) GetFile(ctx context.Context, nodeID, remotePath, localPath string, opts TransferOptions) error {
+	return d.withSFTP(ctx, nodeID, func(client *sftp.Client) error {
+		src, err := client.Open(remotePath)
+		if err != nil {
+			return fmt.Errorf("failed to open remote %s: %w", remotePath, err)
+		}
+		defer src.Close()
+		info, err := src.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat remote %s: %w", remotePath, err)
+		}
+		var offset int64
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if opts.Resume {
+			if local, err := os.Stat(localPath); err == nil && local.Size() <= info.Size() {
+				offset = local.Size()
+				flags = os.O_WRONLY
+			}
+		}
+		dst, err := os.OpenFile(localPath, flags, info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", localPath, err)
+		}
+		defer dst.Close()
+		if err := transfer(ctx, src, dst, offset, info.Size(), opts.Progress); err != nil {
+			return err
+		}
+		if err := dst.Close(); err != nil {
+			return fmt.Errorf("failed to close %s: %w", localPath, err)
+		}
+		if opts.Verify {
+			local, err := os.Open(localPath)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", localPath, err)
+			}
+			defer local.Close()
+			return verify(src, local)
+		}
+		return nil
+	})
+}
+
+// ReadDir lists the remote directory of the node over SFTP.
+func (d *Dialer) ReadDir(ctx context.Context, nodeID, remotePath string) ([]os.FileInfo, error) {
+	var entries []os.FileInfo
+	err := d.withSFTP(ctx, nodeID, func(client *sftp.Client) error {
+		var err error
+		if entries, err = client.ReadDir(remotePath); err != nil {
+			return fmt.Errorf("failed to read remote directory %s: %w", remotePath, err)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// withSFTP opens an SFTP session to the node and closes it after fn returns or the context is done.
+func (d *Dialer) withSFTP(ctx context.Context, nodeID string, fn func(client *sftp.Client) error) error {
+	conn, err := d.Dial(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-stop:
+		}
+	}()
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp: %w", err)
+	}
+	defer client.Close()
+	if err := fn(client); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// transfer copies src to dst from the offset, reporting the progress.
+func transfer(ctx context.Context, src io.ReadSeeker, dst io.WriteSeeker, offset, total int64, progress func(transferred, total int64)) error {
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek source: %w", err)
+	}
+	if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek destination: %w", err)
+	}
+	transferred := offset
+	buf := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, rerr := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to write: %w", err)
+			}
+			transferred += int64(n)
+			if progress != nil {
+				progress(transferred, total)
+			}
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return fmt.Errorf("failed to read: %w", rerr)
+		}
+	}
+}
+
+// verify compares SHA-256 checksums of both files from the beginning.
+func verify(a, b io.ReadSeeker) error {
+	sumA, err := checksum(a)
+	if err != nil {
+		return err
+	}
+	sumB, err := checksum(b)
+	if err != nil {
+		return err
+	}
+	if sumA != sumB {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+func checksum(r io.ReadSeeker) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return sum, fmt.Errorf("failed to seek: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return sum, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
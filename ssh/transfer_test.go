@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutFile(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+	content := bytes.Repeat([]byte("kaginawa"), 10000)
+	local := filepath.Join(dir, "local.conf")
+	remote := filepath.Join(dir, "remote.conf")
+	if err := ioutil.WriteFile(local, content, 0600); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	var last int64
+	err := env.dialer.PutFile(context.Background(), testNodeID, local, remote, TransferOptions{
+		Progress: func(transferred, total int64) {
+			if total != int64(len(content)) {
+				t.Errorf("total expected %d, got %d", len(content), total)
+			}
+			last = transferred
+		},
+		Verify: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last != int64(len(content)) {
+		t.Errorf("last progress expected %d, got %d", len(content), last)
+	}
+	actual, err := ioutil.ReadFile(remote)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if !bytes.Equal(content, actual) {
+		t.Error("uploaded content mismatch")
+	}
+}
+
+func TestPutFileResume(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	local := filepath.Join(dir, "local.bin")
+	remote := filepath.Join(dir, "remote.bin")
+	if err := ioutil.WriteFile(local, content, 0600); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	// The partial file has a marker prefix to detect whether it was kept.
+	partial := append([]byte("RESUMED!!!"), content[10:4000]...)
+	if err := ioutil.WriteFile(remote, partial, 0600); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := env.dialer.PutFile(context.Background(), testNodeID, local, remote, TransferOptions{Resume: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual, err := ioutil.ReadFile(remote)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if !bytes.HasPrefix(actual, []byte("RESUMED!!!")) || !bytes.Equal(actual[10:], content[10:]) {
+		t.Error("expected transfer to resume after the partial file")
+	}
+	err = env.dialer.PutFile(context.Background(), testNodeID, local, remote, TransferOptions{Resume: true, Verify: true})
+	if err != ErrChecksumMismatch {
+		t.Errorf("expected %v, got %v", ErrChecksumMismatch, err)
+	}
+}
+
+func TestGetFile(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+	content := []byte("hostname=test-rpi\n")
+	remote := filepath.Join(dir, "remote.conf")
+	local := filepath.Join(dir, "local.conf")
+	if err := ioutil.WriteFile(remote, content, 0644); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := env.dialer.GetFile(context.Background(), testNodeID, remote, local, TransferOptions{Verify: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual, err := ioutil.ReadFile(local)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if !bytes.Equal(content, actual) {
+		t.Errorf("expected %s, got %s", content, actual)
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	entries, err := env.dialer.ReadDir(context.Background(), testNodeID, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected %d entries, got %d", 2, len(entries))
+	}
+}
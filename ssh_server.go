@@ -1,5 +1,12 @@
 package kaginawa
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
 // SSHServer represents a SSH server entry that managed by Kaginawa Server.
 type SSHServer struct {
 	Host     string `json:"host"`
@@ -8,3 +15,35 @@ type SSHServer struct {
 	Key      string `json:"key"`
 	Password string `json:"password"`
 }
+
+// Validate checks the required attributes of the SSH server entry.
+func (s SSHServer) Validate() error {
+	if len(s.Host) == 0 {
+		return errors.New("most specify a host")
+	}
+	if s.Port < 1 || s.Port > 65535 {
+		return fmt.Errorf("port out of range: %d", s.Port)
+	}
+	if len(s.User) == 0 {
+		return errors.New("most specify a user")
+	}
+	if len(s.Key) == 0 && len(s.Password) == 0 {
+		return errors.New("most specify a key or password")
+	}
+	return nil
+}
+
+func (s SSHServer) form() url.Values {
+	form := url.Values{
+		"host": {s.Host},
+		"port": {strconv.Itoa(s.Port)},
+		"user": {s.User},
+	}
+	if len(s.Key) > 0 {
+		form.Add("key", s.Key)
+	}
+	if len(s.Password) > 0 {
+		form.Add("password", s.Password)
+	}
+	return form
+}
@@ -0,0 +1,48 @@
+package kaginawa
+
+import "testing"
+
+func TestSSHServerValidate(t *testing.T) {
+	tests := []struct {
+		input SSHServer
+		valid bool
+	}{
+		{
+			input: SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Password: "test-pw"},
+			valid: true,
+		},
+		{
+			input: SSHServer{Host: "example.com", Port: 22, User: "kaginawa", Key: "test key"},
+			valid: true,
+		},
+		{
+			input: SSHServer{Port: 22, User: "kaginawa", Password: "test-pw"},
+			valid: false,
+		},
+		{
+			input: SSHServer{Host: "example.com", Port: 0, User: "kaginawa", Password: "test-pw"},
+			valid: false,
+		},
+		{
+			input: SSHServer{Host: "example.com", Port: 65536, User: "kaginawa", Password: "test-pw"},
+			valid: false,
+		},
+		{
+			input: SSHServer{Host: "example.com", Port: 22, Password: "test-pw"},
+			valid: false,
+		},
+		{
+			input: SSHServer{Host: "example.com", Port: 22, User: "kaginawa"},
+			valid: false,
+		},
+	}
+	for i, d := range tests {
+		err := d.input.Validate()
+		if d.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !d.valid && err == nil {
+			t.Errorf("test %d: expected error, got nil.", i)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package kaginawa
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DefaultWatchInterval is the default polling interval of Watcher.
+const DefaultWatchInterval = time.Minute
+
+// EventType is the type of the node event.
+type EventType int
+
+const (
+	// NodeOnline is emitted when a node appears in the alive nodes.
+	NodeOnline EventType = iota
+
+	// NodeOffline is emitted when a node disappears from the alive nodes.
+	NodeOffline
+
+	// NodeRebooted is emitted when the BootTime of a node has changed.
+	NodeRebooted
+
+	// SSHReconnected is emitted when the SSHConnectTime of a node has changed.
+	SSHReconnected
+
+	// AgentUpgraded is emitted when the AgentVersion of a node has changed.
+	AgentUpgraded
+
+	// ReportFailed is emitted when a node sent a new report that Success is false.
+	ReportFailed
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case NodeOnline:
+		return "NodeOnline"
+	case NodeOffline:
+		return "NodeOffline"
+	case NodeRebooted:
+		return "NodeRebooted"
+	case SSHReconnected:
+		return "SSHReconnected"
+	case AgentUpgraded:
+		return "AgentUpgraded"
+	case ReportFailed:
+		return "ReportFailed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event represents a change of a node detected by Watcher.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+
+	// NodeID is the id of the node.
+	NodeID string
+
+	// Previous is the last known report before the change, nil for NodeOnline.
+	Previous *Report
+
+	// Current is the latest report, nil for NodeOffline.
+	Current *Report
+
+	// Time is the detected time of the event.
+	Time time.Time
+}
+
+// Watcher polls alive nodes and emits events of the changes.
+type Watcher struct {
+	// Interval is the polling interval.
+	Interval time.Duration
+
+	// Query is the node query of each poll. Projection must include BootTime, SSHConnectTime and AgentVersion.
+	Query NodeQuery
+
+	// OnError is called when a poll failed. The watcher keeps the last known state and continues polling.
+	OnError func(err error)
+
+	client *Client
+	nodes  map[string]Report
+}
+
+// NewWatcher creates a Watcher that polls alive nodes (within 5 minutes) at the interval.
+// Zero interval means DefaultWatchInterval.
+func NewWatcher(client *Client, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Watcher{
+		Interval: interval,
+		Query:    NodeQuery{Projection: ProjectionFull, Minutes: 5},
+		client:   client,
+	}
+}
+
+// Watch starts polling and returns the event channel.
+// All alive nodes are reported as NodeOnline on the first poll.
+// The channel is closed after the context is done.
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+		for {
+			if !w.poll(ctx, events) {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// poll queries the nodes and emits events. It returns false if the context is done.
+func (w *Watcher) poll(ctx context.Context, events chan<- Event) bool {
+	reports, err := w.client.ListNodes(ctx, w.Query)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		if w.OnError != nil {
+			w.OnError(err)
+		}
+		return true
+	}
+	now := time.Now()
+	current := make(map[string]Report, len(reports))
+	for _, r := range reports {
+		current[r.ID] = r
+	}
+	for _, e := range diffNodes(w.nodes, current, now) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	w.nodes = current
+	return true
+}
+
+// diffNodes compares the node sets and returns events ordered by node ID.
+func diffNodes(previous, current map[string]Report, now time.Time) []Event {
+	var events []Event
+	for _, id := range sortedKeys(current) {
+		cur := current[id]
+		prev, ok := previous[id]
+		if !ok {
+			events = append(events, Event{Type: NodeOnline, NodeID: id, Current: &cur, Time: now})
+			if !cur.Success {
+				events = append(events, Event{Type: ReportFailed, NodeID: id, Current: &cur, Time: now})
+			}
+			continue
+		}
+		if prev.BootTime != cur.BootTime {
+			events = append(events, Event{Type: NodeRebooted, NodeID: id, Previous: &prev, Current: &cur, Time: now})
+		}
+		if prev.SSHConnectTime != cur.SSHConnectTime {
+			events = append(events, Event{Type: SSHReconnected, NodeID: id, Previous: &prev, Current: &cur, Time: now})
+		}
+		if prev.AgentVersion != cur.AgentVersion {
+			events = append(events, Event{Type: AgentUpgraded, NodeID: id, Previous: &prev, Current: &cur, Time: now})
+		}
+		if !cur.Success && prev.ServerTime != cur.ServerTime {
+			events = append(events, Event{Type: ReportFailed, NodeID: id, Previous: &prev, Current: &cur, Time: now})
+		}
+	}
+	for _, id := range sortedKeys(previous) {
+		if _, ok := current[id]; !ok {
+			prev := previous[id]
+			events = append(events, Event{Type: NodeOffline, NodeID: id, Previous: &prev, Time: now})
+		}
+	}
+	return events
+}
+
+func sortedKeys(m map[string]Report) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package kaginawa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiffNodes(t *testing.T) {
+	previous := map[string]Report{
+		"a": {ID: "a", BootTime: 100, SSHConnectTime: 200, AgentVersion: "v0.0.7", Success: true, ServerTime: 1000},
+		"b": {ID: "b", BootTime: 100, SSHConnectTime: 200, AgentVersion: "v0.0.7", Success: true, ServerTime: 1000},
+		"c": {ID: "c", Success: true},
+	}
+	current := map[string]Report{
+		"a": {ID: "a", BootTime: 300, SSHConnectTime: 400, AgentVersion: "v1.0.0", Success: false, ServerTime: 2000},
+		"b": {ID: "b", BootTime: 100, SSHConnectTime: 200, AgentVersion: "v0.0.7", Success: true, ServerTime: 2000},
+		"d": {ID: "d", Success: true},
+	}
+	expected := []struct {
+		typ EventType
+		id  string
+	}{
+		{typ: NodeRebooted, id: "a"},
+		{typ: SSHReconnected, id: "a"},
+		{typ: AgentUpgraded, id: "a"},
+		{typ: ReportFailed, id: "a"},
+		{typ: NodeOnline, id: "d"},
+		{typ: NodeOffline, id: "c"},
+	}
+	events := diffNodes(previous, current, time.Now())
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, e := range expected {
+		if events[i].Type != e.typ || events[i].NodeID != e.id {
+			t.Errorf("event %d: expected %v %s, got %v %s", i, e.typ, e.id, events[i].Type, events[i].NodeID)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	// nil means a transient server error
+	script := [][]Report{
+		{{ID: "a", BootTime: 100, Success: true}},
+		nil,
+		{{ID: "a", BootTime: 200, Success: true}, {ID: "b", Success: true}},
+		{{ID: "b", Success: false, ServerTime: 1}},
+	}
+	var mu sync.Mutex
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		state := script[len(script)-1]
+		if polls < len(script) {
+			state = script[polls]
+		}
+		polls++
+		mu.Unlock()
+		if state == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(state); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, testAPIKey, WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	watcher := NewWatcher(client, 5*time.Millisecond)
+	errorCount := 0
+	watcher.OnError = func(err error) { errorCount++ }
+	expected := []EventType{NodeOnline, NodeRebooted, NodeOnline, ReportFailed, NodeOffline}
+	var actual []EventType
+	for e := range watcher.Watch(ctx) {
+		actual = append(actual, e.Type)
+		if len(actual) == len(expected) {
+			cancel()
+		}
+	}
+	if len(actual) < len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Errorf("event %d: expected %v, got %v", i, expected[i], actual[i])
+		}
+	}
+	if errorCount != 1 {
+		t.Errorf("expected %d error, got %d", 1, errorCount)
+	}
+}